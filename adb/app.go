@@ -0,0 +1,488 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var componentRE = regexp.MustCompile(`^[\w.]+/[\w.$]+$`)
+
+// ResolveLaunchActivity returns launchable component of package, eg: com.example/.MainActivity
+// Require Android 7.0+ which have cmd package resolve-activity
+func (d *Device) ResolveLaunchActivity(packageName string) (component string, err error) {
+	return d.ResolveLaunchActivityContext(context.Background(), packageName)
+}
+
+func (d *Device) ResolveLaunchActivityContext(ctx context.Context, packageName string) (component string, err error) {
+	output, err := d.RunCommandContext(ctx, "cmd", "package", "resolve-activity", "--brief",
+		"-a", "android.intent.action.MAIN", "-c", "android.intent.category.LAUNCHER", packageName)
+	if err != nil {
+		return
+	}
+	return parseResolveActivity(output)
+}
+
+// output example:
+// priority=0 preferredOrder=0 match=0x108000 specificIndex=-1 isDefault=false
+// com.example/.MainActivity
+func parseResolveActivity(output string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if !componentRE.MatchString(last) {
+		return "", fmt.Errorf("resolve-activity: %s", strings.TrimSpace(output))
+	}
+	return last, nil
+}
+
+// LaunchTiming is parsed from output of am start -W
+type LaunchTiming struct {
+	Status    string        `json:"status"`
+	Activity  string        `json:"activity"`
+	ThisTime  time.Duration `json:"thisTime"`
+	TotalTime time.Duration `json:"totalTime"`
+	WaitTime  time.Duration `json:"waitTime"`
+}
+
+// StartActivity run am start -n <component>.
+// When wait is true, am start -W is used and launch timing returned
+func (d *Device) StartActivity(component string, wait bool) (timing *LaunchTiming, err error) {
+	return d.StartActivityContext(context.Background(), component, wait)
+}
+
+func (d *Device) StartActivityContext(ctx context.Context, component string, wait bool) (timing *LaunchTiming, err error) {
+	args := []string{"am", "start"}
+	if wait {
+		args = append(args, "-W")
+	}
+	args = append(args, "-n", component)
+	output, err := d.RunCommandContext(ctx, args...)
+	if err != nil {
+		return
+	}
+	if strings.Contains(output, "Error:") {
+		return nil, errors.New(strings.TrimSpace(output))
+	}
+	if !wait {
+		return nil, nil
+	}
+	return parseLaunchTiming(output), nil
+}
+
+// output example:
+// Starting: Intent { cmp=com.example/.MainActivity }
+// Status: ok
+// Activity: com.example/.MainActivity
+// ThisTime: 347
+// TotalTime: 347
+// WaitTime: 371
+// Complete
+func parseLaunchTiming(output string) *LaunchTiming {
+	timing := &LaunchTiming{}
+	for _, line := range strings.Split(output, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), ": ", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		ms, _ := strconv.Atoi(kv[1])
+		switch kv[0] {
+		case "Status":
+			timing.Status = kv[1]
+		case "Activity":
+			timing.Activity = kv[1]
+		case "ThisTime":
+			timing.ThisTime = time.Duration(ms) * time.Millisecond
+		case "TotalTime":
+			timing.TotalTime = time.Duration(ms) * time.Millisecond
+		case "WaitTime":
+			timing.WaitTime = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return timing
+}
+
+// ForegroundApp is the app owns the focused window
+type ForegroundApp struct {
+	Package  string `json:"package"`
+	Activity string `json:"activity"`
+	Pid      int    `json:"pid"`
+}
+
+var (
+	// mCurrentFocus=Window{7d1d5b3 u0 com.example/com.example.MainActivity}
+	windowFocusRE = regexp.MustCompile(`mCurrentFocus=Window\{\S+(?: u\d+)? ([\w.]+)/([\w.$]+)`)
+	// mFocusedApp=AppWindowToken{41d4d950 token=Token{41c0c7e8 ActivityRecord{41b5ab98 u0 com.example/.MainActivity t9}}}
+	focusedAppRE = regexp.MustCompile(`mFocusedApp=.*?ActivityRecord\{\S+(?: u\d+)? ([\w.]+)/([\w.$]+)`)
+	// mResumedActivity: ActivityRecord{4e8c2e3 u0 com.example/.MainActivity t12}
+	resumedActivityRE = regexp.MustCompile(`(?:mResumedActivity|ResumedActivity|topResumedActivity)[:=] ?ActivityRecord\{\S+(?: u\d+)? ([\w.]+)/([\w.$]+)`)
+	// same as pidcat.py --current, TaskRecord{41a6b8f0 #9 A=com.example U=0 sz=1}
+	taskRecordRE = regexp.MustCompile(`TaskRecord.*A[= ](?:\d+:)?([^ ^}]*)`)
+)
+
+// parseForegroundApp find package and activity in output of dumpsys window or dumpsys activity
+func parseForegroundApp(output string) (app *ForegroundApp, err error) {
+	for _, re := range []*regexp.Regexp{windowFocusRE, focusedAppRE, resumedActivityRE} {
+		m := re.FindStringSubmatch(output)
+		if m == nil {
+			continue
+		}
+		activity := m[2]
+		if strings.HasPrefix(activity, ".") {
+			activity = m[1] + activity
+		}
+		return &ForegroundApp{Package: m[1], Activity: activity}, nil
+	}
+	if m := taskRecordRE.FindStringSubmatch(output); m != nil {
+		return &ForegroundApp{Package: m[1]}, nil
+	}
+	return nil, errors.New("no focused app found")
+}
+
+// currentActivity returns package and activity of the focused window.
+// dumpsys window works on most devices, dumpsys activity is used when keyguard or
+// system window is focused
+func (d *Device) currentActivity(ctx context.Context) (app *ForegroundApp, err error) {
+	for _, args := range [][]string{
+		{"dumpsys", "window", "windows"},
+		{"dumpsys", "window"},
+		{"dumpsys", "activity", "activities"},
+	} {
+		output, er := d.RunCommandContext(ctx, args...)
+		if er != nil {
+			return nil, er
+		}
+		if app, err = parseForegroundApp(output); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// CurrentApp returns package, activity and pid of the focused window
+// Different dumpsys output formats from Android 4 to Android 14 are supported
+func (d *Device) CurrentApp() (app *ForegroundApp, err error) {
+	return d.CurrentAppContext(context.Background())
+}
+
+func (d *Device) CurrentAppContext(ctx context.Context) (app *ForegroundApp, err error) {
+	app, err = d.currentActivity(ctx)
+	if err != nil {
+		return
+	}
+	app.Pid, _ = d.PidofContext(ctx, app.Package)
+	return app, nil
+}
+
+// Pidof returns pid of process, pidof is not available before Android 6.0, ps is used instead
+func (d *Device) Pidof(name string) (pid int, err error) {
+	return d.PidofContext(context.Background(), name)
+}
+
+func (d *Device) PidofContext(ctx context.Context, name string) (pid int, err error) {
+	output, err := d.RunCommandContext(ctx, "pidof", name)
+	if err != nil {
+		return
+	}
+	if fields := strings.Fields(output); len(fields) > 0 {
+		if pid, err = strconv.Atoi(fields[0]); err == nil {
+			return
+		}
+	}
+	// ps shows only processes of shell on Android 8.0+ without -A
+	for _, args := range [][]string{{"ps", "-A"}, {"ps"}} {
+		output, err = d.RunCommandContext(ctx, args...)
+		if err != nil {
+			return
+		}
+		if pid = parsePsPid(output, name); pid != 0 {
+			return pid, nil
+		}
+	}
+	return 0, fmt.Errorf("process %s not found", name)
+}
+
+// output example:
+// USER      PID   PPID  VSIZE  RSS     WCHAN    PC        NAME
+// u0_a59    2130  175   1012744 61952 ffffffff 00000000 S com.example
+func parsePsPid(output string, name string) int {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[len(fields)-1] != name {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[1]); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
+
+// WaitForeground wait until package is in the foreground
+func (d *Device) WaitForeground(packageName string, timeout time.Duration) error {
+	return d.WaitForegroundContext(context.Background(), packageName, timeout)
+}
+
+func (d *Device) WaitForegroundContext(ctx context.Context, packageName string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		current := ""
+		app, err := d.currentActivity(ctx)
+		if err == nil {
+			if app.Package == packageName {
+				return nil
+			}
+			current = app.Package
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wait %s foreground timeout, current: %s", packageName, current)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// AppInfo is parsed from dumpsys package <package-name>
+type AppInfo struct {
+	PackageName      string   `json:"packageName"`
+	VersionName      string   `json:"versionName"`
+	VersionCode      int      `json:"versionCode"`
+	MinSdk           int      `json:"minSdk,omitempty"`
+	TargetSdk        int      `json:"targetSdk,omitempty"`
+	Installer        string   `json:"installer"`
+	CodePath         string   `json:"codePath"`
+	DataDir          string   `json:"dataDir"`
+	FirstInstallTime string   `json:"firstInstallTime"`
+	LastUpdateTime   string   `json:"lastUpdateTime"`
+	Permissions      []string `json:"permissions"` // granted permissions
+}
+
+// AppInfo returns package version, installer, paths and granted permissions
+func (d *Device) AppInfo(packageName string) (info *AppInfo, err error) {
+	return d.AppInfoContext(context.Background(), packageName)
+}
+
+func (d *Device) AppInfoContext(ctx context.Context, packageName string) (info *AppInfo, err error) {
+	output, err := d.RunCommandContext(ctx, "dumpsys", "package", packageName)
+	if err != nil {
+		return
+	}
+	return parseAppInfo(packageName, output)
+}
+
+var (
+	appFieldRE      = regexp.MustCompile(`(\w+)=(.*)`)
+	appIntFieldRE   = regexp.MustCompile(`(versionCode|minSdk|targetSdk)=(\d+)`)
+	grantedPermRE   = regexp.MustCompile(`^\s+([\w.]+): granted=true`)
+	packageHeaderRE = regexp.MustCompile(`^\s*Package \[([\w.]+)\]`)
+)
+
+func parseAppInfo(packageName, output string) (*AppInfo, error) {
+	info := &AppInfo{
+		Permissions: make([]string, 0),
+	}
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		if m := packageHeaderRE.FindStringSubmatch(line); m != nil {
+			if found { // only the first block is used
+				break
+			}
+			if m[1] != packageName {
+				continue
+			}
+			found = true
+			info.PackageName = m[1]
+			continue
+		}
+		if !found {
+			continue
+		}
+		if strings.HasPrefix(line, "Hidden system packages:") {
+			break
+		}
+		if m := grantedPermRE.FindStringSubmatch(line); m != nil {
+			info.Permissions = append(info.Permissions, m[1])
+			continue
+		}
+		for _, m := range appIntFieldRE.FindAllStringSubmatch(line, -1) {
+			n, _ := strconv.Atoi(m[2])
+			switch m[1] {
+			case "versionCode":
+				info.VersionCode = n
+			case "minSdk":
+				info.MinSdk = n
+			case "targetSdk":
+				info.TargetSdk = n
+			}
+		}
+		m := appFieldRE.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		switch m[1] {
+		case "versionName":
+			info.VersionName = m[2]
+		case "installerPackageName":
+			info.Installer = m[2]
+		case "codePath":
+			info.CodePath = m[2]
+		case "dataDir":
+			info.DataDir = m[2]
+		case "firstInstallTime":
+			info.FirstInstallTime = m[2]
+		case "lastUpdateTime":
+			info.LastUpdateTime = m[2]
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("package %s not found", packageName)
+	}
+	return info, nil
+}
+
+// ListPackages returns package names, args are passed to pm list packages, eg: -3
+func (d *Device) ListPackages(args ...string) (packages []string, err error) {
+	return d.ListPackagesContext(context.Background(), args...)
+}
+
+func (d *Device) ListPackagesContext(ctx context.Context, args ...string) (packages []string, err error) {
+	output, err := d.RunCommandContext(ctx, append([]string{"pm", "list", "packages"}, args...)...)
+	if err != nil {
+		return
+	}
+	packages = make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package:") {
+			packages = append(packages, strings.TrimPrefix(line, "package:"))
+		}
+	}
+	return
+}
+
+var installFailureRE = regexp.MustCompile(`Failure \[([A-Z_]+)`)
+
+// InstallError is returned when pm install fails, Code is parsed from output, eg: INSTALL_FAILED_VERSION_DOWNGRADE
+type InstallError struct {
+	Code   string
+	Output string
+}
+
+func (e *InstallError) Error() string {
+	if e.Code == "" {
+		return "install failed: " + e.Output
+	}
+	return "install failed: " + e.Code
+}
+
+// Install push local apk to /data/local/tmp and install it with pm install,
+// args are passed to pm install, eg: -r (replace), -g (grant permissions), -d (allow downgrade)
+func (d *Device) Install(apkPath string, args ...string) error {
+	return d.InstallContext(context.Background(), apkPath, args...)
+}
+
+func (d *Device) InstallContext(ctx context.Context, apkPath string, args ...string) error {
+	f, err := os.Open(apkPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	remotePath := fmt.Sprintf("/data/local/tmp/fa-install-%d.apk", time.Now().UnixNano())
+	if err := d.PushContext(ctx, f, remotePath, 0644); err != nil {
+		return err
+	}
+	// removed even if ctx is done
+	defer d.RunCommand("rm", "-f", remotePath)
+	output, err := d.RunCommandContext(ctx, append(append([]string{"pm", "install"}, args...), remotePath)...)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(output, "Success") {
+		ierr := &InstallError{Output: strings.TrimSpace(output)}
+		if m := installFailureRE.FindStringSubmatch(output); m != nil {
+			ierr.Code = m[1]
+		}
+		return ierr
+	}
+	return nil
+}
+
+// AppStop force stop package
+func (d *Device) AppStop(packageName string) error {
+	return d.AppStopContext(context.Background(), packageName)
+}
+
+func (d *Device) AppStopContext(ctx context.Context, packageName string) error {
+	_, err := d.RunCommandContext(ctx, "am", "force-stop", packageName)
+	return err
+}
+
+// AppClear clear package data
+func (d *Device) AppClear(packageName string) error {
+	return d.AppClearContext(context.Background(), packageName)
+}
+
+func (d *Device) AppClearContext(ctx context.Context, packageName string) error {
+	return d.runPmSuccess(ctx, "clear", packageName)
+}
+
+// AppUninstall remove package, data and cache directories are kept if keepData is true
+func (d *Device) AppUninstall(packageName string, keepData bool) error {
+	return d.AppUninstallContext(context.Background(), packageName, keepData)
+}
+
+func (d *Device) AppUninstallContext(ctx context.Context, packageName string, keepData bool) error {
+	if keepData {
+		return d.runPmSuccess(ctx, "uninstall", "-k", packageName)
+	}
+	return d.runPmSuccess(ctx, "uninstall", packageName)
+}
+
+// GrantPermission grant runtime permission to package
+func (d *Device) GrantPermission(packageName, permission string) error {
+	return d.GrantPermissionContext(context.Background(), packageName, permission)
+}
+
+func (d *Device) GrantPermissionContext(ctx context.Context, packageName, permission string) error {
+	return d.runPmSilent(ctx, "grant", packageName, permission)
+}
+
+// RevokePermission revoke runtime permission from package
+func (d *Device) RevokePermission(packageName, permission string) error {
+	return d.RevokePermissionContext(context.Background(), packageName, permission)
+}
+
+func (d *Device) RevokePermissionContext(ctx context.Context, packageName, permission string) error {
+	return d.runPmSilent(ctx, "revoke", packageName, permission)
+}
+
+// runPmSuccess run pm command which print Success when succeed
+func (d *Device) runPmSuccess(ctx context.Context, args ...string) error {
+	output, err := d.RunCommandContext(ctx, append([]string{"pm"}, args...)...)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(output, "Success") {
+		return fmt.Errorf("pm %s: %s", args[0], strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// runPmSilent run pm command which print nothing when succeed
+func (d *Device) runPmSilent(ctx context.Context, args ...string) error {
+	output, err := d.RunCommandContext(ctx, append([]string{"pm"}, args...)...)
+	if err != nil {
+		return err
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return fmt.Errorf("pm %s: %s", args[0], output)
+	}
+	return nil
+}
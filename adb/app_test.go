@@ -0,0 +1,93 @@
+package adb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResolveActivity(t *testing.T) {
+	component, err := parseResolveActivity("priority=0 preferredOrder=0 match=0x108000 specificIndex=-1 isDefault=false\ncom.example/.MainActivity\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "com.example/.MainActivity", component)
+
+	_, err = parseResolveActivity("No activity found\n")
+	assert.Error(t, err)
+}
+
+func TestParseLaunchTiming(t *testing.T) {
+	timing := parseLaunchTiming("Starting: Intent { cmp=com.example/.MainActivity }\nStatus: ok\nActivity: com.example/.MainActivity\nThisTime: 347\nTotalTime: 347\nWaitTime: 371\nComplete\n")
+	assert.Equal(t, "ok", timing.Status)
+	assert.Equal(t, "com.example/.MainActivity", timing.Activity)
+	assert.Equal(t, 347*time.Millisecond, timing.TotalTime)
+	assert.Equal(t, 371*time.Millisecond, timing.WaitTime)
+}
+
+func TestParseAppInfo(t *testing.T) {
+	output := `Activity Resolver Table:
+  Non-Data Actions:
+Packages:
+  Package [com.example] (2b1b4a3):
+    userId=10123
+    codePath=/data/app/com.example-1
+    versionCode=12 minSdk=21 targetSdk=28
+    versionName=1.2.0
+    dataDir=/data/user/0/com.example
+    firstInstallTime=2018-12-01 10:00:01
+    lastUpdateTime=2018-12-02 11:00:01
+    installerPackageName=com.android.vending
+    install permissions:
+      android.permission.INTERNET: granted=true
+    User 0: ceDataInode=12345 installed=true hidden=false
+      runtime permissions:
+        android.permission.CAMERA: granted=true
+        android.permission.READ_CONTACTS: granted=false
+`
+	info, err := parseAppInfo("com.example", output)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "1.2.0", info.VersionName)
+	assert.Equal(t, 12, info.VersionCode)
+	assert.Equal(t, 28, info.TargetSdk)
+	assert.Equal(t, "com.android.vending", info.Installer)
+	assert.Equal(t, "/data/app/com.example-1", info.CodePath)
+	assert.Equal(t, "2018-12-01 10:00:01", info.FirstInstallTime)
+	assert.Equal(t, []string{"android.permission.INTERNET", "android.permission.CAMERA"}, info.Permissions)
+
+	_, err = parseAppInfo("com.notexists", output)
+	assert.Error(t, err)
+}
+
+func TestParseForegroundApp(t *testing.T) {
+	for _, tc := range []struct {
+		output   string
+		pkg      string
+		activity string
+	}{
+		{"  mCurrentFocus=Window{7d1d5b3 u0 com.example/com.example.MainActivity}", "com.example", "com.example.MainActivity"},
+		{"  mCurrentFocus=Window{41e3c1d8 com.android.launcher/com.android.launcher2.Launcher}", "com.android.launcher", "com.android.launcher2.Launcher"},
+		{"  mCurrentFocus=null\n  mFocusedApp=AppWindowToken{41d4d950 token=Token{41c0c7e8 ActivityRecord{41b5ab98 u0 com.example/.MainActivity t9}}}", "com.example", "com.example.MainActivity"},
+		{"  mFocusedApp=ActivityRecord{a2c5c61 u0 com.example/.MainActivity t31}", "com.example", "com.example.MainActivity"},
+		{"    mResumedActivity: ActivityRecord{4e8c2e3 u0 com.example/.MainActivity t12}", "com.example", "com.example.MainActivity"},
+		{"  ResumedActivity: ActivityRecord{4e8c2e3 u0 com.example/.MainActivity t12}", "com.example", "com.example.MainActivity"},
+		{"  * TaskRecord{41a6b8f0 #9 A=com.example U=0 sz=1}", "com.example", ""},
+	} {
+		app, err := parseForegroundApp(tc.output)
+		if !assert.NoError(t, err, tc.output) {
+			continue
+		}
+		assert.Equal(t, tc.pkg, app.Package)
+		assert.Equal(t, tc.activity, app.Activity)
+	}
+
+	_, err := parseForegroundApp("mCurrentFocus=null")
+	assert.Error(t, err)
+}
+
+func TestParsePsPid(t *testing.T) {
+	output := "USER      PID   PPID  VSIZE  RSS     WCHAN    PC        NAME\nu0_a59    2130  175   1012744 61952 ffffffff 00000000 S com.example\nu0_a59    2150  175   1012744 61952 ffffffff 00000000 S com.example:remote\n"
+	assert.Equal(t, 2130, parsePsPid(output, "com.example"))
+	assert.Equal(t, 0, parsePsPid(output, "com.other"))
+}
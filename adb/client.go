@@ -1,6 +1,7 @@
 package adb
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os/exec"
@@ -10,44 +11,88 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	defaultServerAddr  = "127.0.0.1:5037"
+	defaultDialTimeout = 2 * time.Second
+)
+
+// ClientOptions configures how Client connects to adb server
+type ClientOptions struct {
+	Addr        string        // adb server address, default 127.0.0.1:5037
+	DialTimeout time.Duration // timeout of connecting to adb server, default 2s
+	ADBPath     string        // adb binary used to start server, default adb in PATH
+	AutoStart   bool          // run "adb start-server" when adb server can not be connected
+}
+
 type Client struct {
 	Addr string
+	opts ClientOptions
 }
 
+// NewClient returns client which starts adb server automatically
 func NewClient(addr string) *Client {
-	if addr == "" {
-		addr = "127.0.0.1:5037"
+	return NewClientWithOptions(ClientOptions{
+		Addr:      addr,
+		AutoStart: true,
+	})
+}
+
+func NewClientWithOptions(opts ClientOptions) *Client {
+	if opts.Addr == "" {
+		opts.Addr = defaultServerAddr
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	if opts.ADBPath == "" {
+		opts.ADBPath = "adb"
 	}
 	return &Client{
-		Addr: addr,
+		Addr: opts.Addr,
+		opts: opts,
 	}
 }
 
-func (c *Client) dial() (conn *ADBConn, err error) {
-	nc, err := net.DialTimeout("tcp", c.Addr, 2*time.Second)
+func (c *Client) dialServer(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.opts.DialTimeout}
+	return dialer.DialContext(ctx, "tcp", c.Addr)
+}
+
+// dial connect to adb server, the connection is closed when ctx is done
+func (c *Client) dial(ctx context.Context) (conn *ADBConn, err error) {
+	nc, err := c.dialServer(ctx)
+	if err != nil && c.opts.AutoStart && ctx.Err() == nil {
+		if startErr := c.StartServerContext(ctx); startErr != nil {
+			return nil, &serverUnavailableError{addr: c.Addr, err: errors.Wrapf(startErr, "%v, adb start-server", err)}
+		}
+		nc, err = c.dialServer(ctx)
+	}
 	if err != nil {
-		if err = c.StartServer(); err != nil {
-			err = errors.Wrap(err, "adb start-server")
-			return
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-		nc, err = net.DialTimeout("tcp", c.Addr, 2*time.Second)
+		return nil, &serverUnavailableError{addr: c.Addr, err: err}
 	}
-	return NewADBConn(nc), err
+	conn = NewADBConn(nc)
+	conn.closeOnDone(ctx)
+	return conn, nil
 }
 
-func (c *Client) roundTrip(data string) (conn *ADBConn, err error) {
-	conn, err = c.dial()
+func (c *Client) roundTrip(ctx context.Context, data string) (conn *ADBConn, err error) {
+	conn, err = c.dial(ctx)
 	if err != nil {
 		return
 	}
 	if len(data) > 0 {
-		err = conn.Encode([]byte(data))
+		if err = conn.Encode([]byte(data)); err != nil {
+			conn.Close()
+		}
 	}
 	return
 }
 
-func (c *Client) roundTripSingleResponse(data string) (string, error) {
-	conn, err := c.roundTrip(data)
+func (c *Client) roundTripSingleResponse(ctx context.Context, data string) (string, error) {
+	conn, err := c.roundTrip(ctx, data)
 	if err != nil {
 		return "", err
 	}
@@ -60,7 +105,11 @@ func (c *Client) roundTripSingleResponse(data string) (string, error) {
 
 // ServerVersion returns int. 39 means 1.0.39
 func (c *Client) ServerVersion() (v int, err error) {
-	verstr, err := c.roundTripSingleResponse("host:version")
+	return c.ServerVersionContext(context.Background())
+}
+
+func (c *Client) ServerVersionContext(ctx context.Context) (v int, err error) {
+	verstr, err := c.roundTripSingleResponse(ctx, "host:version")
 	if err != nil {
 		return
 	}
@@ -75,11 +124,18 @@ const (
 	StateDisconnected = DeviceState("disconnected")
 	StateOffline      = DeviceState("offline")
 	StateOnline       = DeviceState("device")
+	StateRecovery     = DeviceState("recovery")
+	StateSideload     = DeviceState("sideload")
+	StateBootloader   = DeviceState("bootloader")
 )
 
 // ListDevices returns the list of connected devices
 func (c *Client) ListDevices() (devs []*Device, err error) {
-	lines, err := c.roundTripSingleResponse("host:devices")
+	return c.ListDevicesContext(context.Background())
+}
+
+func (c *Client) ListDevicesContext(ctx context.Context) (devs []*Device, err error) {
+	lines, err := c.roundTripSingleResponse(ctx, "host:devices")
 	if err != nil {
 		return nil, err
 	}
@@ -95,21 +151,95 @@ func (c *Client) ListDevices() (devs []*Device, err error) {
 	return
 }
 
+// DeviceInfo is a line of host:devices-l
+type DeviceInfo struct {
+	Serial      string      `json:"serial"`
+	State       DeviceState `json:"state"`
+	USB         string      `json:"usb,omitempty"`
+	Product     string      `json:"product,omitempty"`
+	Model       string      `json:"model,omitempty"`
+	Device      string      `json:"device,omitempty"`
+	TransportID string      `json:"transportId,omitempty"`
+}
+
+// parseDeviceInfos parse output of host:devices-l, eg:
+// 3578298f  device usb:1-1 product:dipper model:MI_8 device:dipper transport_id:1
+func parseDeviceInfos(lines string) []DeviceInfo {
+	infos := make([]DeviceInfo, 0)
+	for _, line := range strings.Split(lines, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		info := DeviceInfo{Serial: fields[0], State: DeviceState(fields[1])}
+		for _, field := range fields[2:] {
+			kv := strings.SplitN(field, ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "usb":
+				info.USB = kv[1]
+			case "product":
+				info.Product = kv[1]
+			case "model":
+				info.Model = kv[1]
+			case "device":
+				info.Device = kv[1]
+			case "transport_id":
+				info.TransportID = kv[1]
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// ListDeviceInfos returns serial, state, model etc of connected devices
+func (c *Client) ListDeviceInfos() ([]DeviceInfo, error) {
+	return c.ListDeviceInfosContext(context.Background())
+}
+
+func (c *Client) ListDeviceInfosContext(ctx context.Context) ([]DeviceInfo, error) {
+	lines, err := c.roundTripSingleResponse(ctx, "host:devices-l")
+	if err != nil {
+		return nil, err
+	}
+	return parseDeviceInfos(lines), nil
+}
+
 func (c *Client) StartServer() (err error) {
-	cmd := exec.Command("adb", "start-server")
-	return cmd.Run()
+	return c.StartServerContext(context.Background())
+}
+
+// StartServerContext run "adb start-server" with ADBPath, server is started on port of Addr
+func (c *Client) StartServerContext(ctx context.Context) error {
+	args := []string{"start-server"}
+	if _, port, err := net.SplitHostPort(c.Addr); err == nil && port != "5037" {
+		args = []string{"-P", port, "start-server"}
+	}
+	return exec.CommandContext(ctx, c.opts.ADBPath, args...).Run()
 }
 
 // KillServer tells the server to quit immediately
 func (c *Client) KillServer() error {
-	conn, err := c.roundTrip("host:kill")
-	if err != nil {
-		if _, ok := err.(net.Error); ok { // adb is already stopped if connection refused
-			return nil
+	return c.KillServerContext(context.Background())
+}
+
+func (c *Client) KillServerContext(ctx context.Context) error {
+	nc, err := c.dialServer(ctx)
+	if err != nil { // adb is already stopped if connection refused
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		return err
+		return nil
 	}
+	conn := NewADBConn(nc)
+	conn.closeOnDone(ctx)
 	defer conn.Close()
+	if err := conn.EncodeString("host:kill"); err != nil {
+		return err
+	}
 	return conn.CheckOKAY()
 }
 
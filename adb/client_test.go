@@ -1,6 +1,12 @@
 package adb
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
 	"testing"
 	"time"
 
@@ -48,3 +54,49 @@ func TestDeviceRunCommand(t *testing.T) {
 	}
 	assert.Equal(t, "/\n", output)
 }
+
+func TestParseDeviceInfos(t *testing.T) {
+	infos := parseDeviceInfos(`3578298f               device usb:1-1 product:dipper model:MI_8 device:dipper transport_id:1
+192.168.1.23:5555      offline transport_id:2
+`)
+	assert.Equal(t, []DeviceInfo{
+		{Serial: "3578298f", State: StateOnline, USB: "1-1", Product: "dipper", Model: "MI_8", Device: "dipper", TransportID: "1"},
+		{Serial: "192.168.1.23:5555", State: StateOffline, TransportID: "2"},
+	}, infos)
+}
+
+func TestClientAutoStart(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	_, err = NewClientWithOptions(ClientOptions{Addr: addr, ADBPath: "/nonexistent/adb"}).ServerVersion()
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "start-server")
+	assert.True(t, errors.Is(err, ErrServerNotAvailable))
+
+	_, err = NewClientWithOptions(ClientOptions{Addr: addr, ADBPath: "/nonexistent/adb", AutoStart: true}).ServerVersion()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "start-server")
+	assert.True(t, errors.Is(err, ErrServerNotAvailable))
+}
+
+func TestRunCommandContext(t *testing.T) {
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		rw.WriteString("started\n")
+		rw.Flush()
+		io.Copy(ioutil.Discard, rw) // never exit until connection closed
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := device.RunCommandContext(ctx, "sleep", "100")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second)
+}
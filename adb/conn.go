@@ -2,20 +2,26 @@ package adb
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
 	"regexp"
 	"strconv"
-
-	"github.com/pkg/errors"
+	"sync"
 )
 
 type ADBConn struct {
 	rw io.ReadWriter
 	io.Closer
 	err error
+
+	ctx         context.Context
+	stop        chan struct{}
+	stopOnce    sync.Once
+	watchDone   chan struct{}
+	closedByCtx bool
 }
 
 func NewADBConn(conn net.Conn) *ADBConn {
@@ -30,15 +36,69 @@ func NewADBConn(conn net.Conn) *ADBConn {
 	}
 }
 
+// closeOnDone close conn when ctx is done, Read and Write return ctx.Err() after that
+func (conn *ADBConn) closeOnDone(ctx context.Context) {
+	if ctx.Done() == nil {
+		return
+	}
+	conn.ctx = ctx
+	conn.stop = make(chan struct{})
+	conn.watchDone = make(chan struct{})
+	go func() {
+		defer close(conn.watchDone)
+		select {
+		case <-ctx.Done():
+			conn.closedByCtx = true
+			conn.Closer.Close()
+		case <-conn.stop:
+		}
+	}()
+}
+
+// stopWatch stop closing conn when ctx is done, ctx.Err() is returned if conn is already closed
+func (conn *ADBConn) stopWatch() error {
+	if conn.stop == nil {
+		return nil
+	}
+	conn.stopOnce.Do(func() {
+		close(conn.stop)
+	})
+	<-conn.watchDone
+	if conn.closedByCtx {
+		return conn.ctx.Err()
+	}
+	conn.ctx = nil
+	return nil
+}
+
+// Close close the connection and stop watching context
+func (conn *ADBConn) Close() error {
+	conn.stopOnce.Do(func() {
+		if conn.stop != nil {
+			close(conn.stop)
+		}
+	})
+	return conn.Closer.Close()
+}
+
 func (conn *ADBConn) Err() error {
 	return conn.err
 }
 
+// ctxErr replace error caused by closing connection with ctx.Err()
+func (conn *ADBConn) ctxErr(err error) error {
+	if err != nil && conn.ctx != nil && conn.ctx.Err() != nil {
+		return conn.ctx.Err()
+	}
+	return err
+}
+
 func (conn *ADBConn) Read(p []byte) (n int, err error) {
 	if conn.err != nil {
 		return 0, conn.err
 	}
 	n, err = conn.rw.Read(p)
+	err = conn.ctxErr(err)
 	conn.err = err
 	return
 }
@@ -48,6 +108,7 @@ func (conn *ADBConn) Write(p []byte) (n int, err error) {
 		return 0, conn.err
 	}
 	n, err = conn.rw.Write(p)
+	err = conn.ctxErr(err)
 	conn.err = err
 	return
 }
@@ -125,7 +186,10 @@ func (conn *ADBConn) DecodeString() (string, error) {
 
 // CheckOKAY check OKAY, or FAIL
 func (conn *ADBConn) CheckOKAY() error {
-	status, _ := conn.ReadNString(4)
+	status, err := conn.ReadNString(4)
+	if err != nil {
+		return err
+	}
 	switch status {
 	case _OKAY:
 		return nil
@@ -134,7 +198,7 @@ func (conn *ADBConn) CheckOKAY() error {
 		if err != nil {
 			return err
 		}
-		return errors.Wrap(errors.New(data), "respCheck")
+		return &ServerError{Message: data}
 	default:
 		return fmt.Errorf("Unexpected response: %s, should be OKAY or FAIL", strconv.Quote(status))
 	}
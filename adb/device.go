@@ -1,12 +1,15 @@
 package adb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,47 +27,144 @@ func (d *Device) String() string {
 	// return fmt.Sprintf("%s:%v", ad.serial, ad.State)
 }
 
-func (d *Device) Serial() (serial string, err error) {
-	return
+// Serial query serial number of device from adb server, works for any descriptor type
+func (d *Device) Serial() (string, error) {
+	return d.SerialContext(context.Background())
+}
+
+func (d *Device) SerialContext(ctx context.Context) (string, error) {
+	return d.hostQuery(ctx, "get-serialno")
+}
+
+// State returns state of device, eg: device, offline, unauthorized, recovery
+func (d *Device) State() (DeviceState, error) {
+	return d.StateContext(context.Background())
+}
+
+func (d *Device) StateContext(ctx context.Context) (DeviceState, error) {
+	state, err := d.hostQuery(ctx, "get-state")
+	return DeviceState(state), err
+}
+
+// DevPath returns usb device path, eg: usb:1-1
+func (d *Device) DevPath() (string, error) {
+	return d.DevPathContext(context.Background())
+}
+
+func (d *Device) DevPathContext(ctx context.Context) (string, error) {
+	return d.hostQuery(ctx, "get-devpath")
+}
+
+// Features returns features supported by both adb server and device, eg: shell_v2, cmd, stat_v2
+func (d *Device) Features() ([]string, error) {
+	return d.FeaturesContext(context.Background())
+}
+
+func (d *Device) FeaturesContext(ctx context.Context) ([]string, error) {
+	resp, err := d.hostQuery(ctx, "features")
+	if err != nil {
+		return nil, err
+	}
+	features := make([]string, 0)
+	for _, f := range strings.Split(resp, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			features = append(features, f)
+		}
+	}
+	return features, nil
+}
+
+// hostQuery send <host-prefix>:<request> and returns response string
+func (d *Device) hostQuery(ctx context.Context, request string) (string, error) {
+	resp, err := d.client.roundTripSingleResponse(ctx, d.descriptor.getHostPrefix()+":"+request)
+	return strings.TrimSpace(resp), err
+}
+
+// waitForRequest returns wait-for-<transport>-<state>, state can be StateOnline, StateRecovery,
+// StateSideload, StateBootloader, StateDisconnected or "any"
+func (d *Device) waitForRequest(state DeviceState) (string, error) {
+	transport := "any"
+	switch d.descriptor.descriptorType {
+	case DeviceUsb:
+		transport = "usb"
+	case DeviceLocal:
+		transport = "local"
+	}
+	switch state {
+	case StateOnline, StateRecovery, StateSideload, StateBootloader, "any":
+	case StateDisconnected:
+		state = "disconnect"
+	default:
+		return "", fmt.Errorf("wait for state %q is not supported", state)
+	}
+	return fmt.Sprintf("wait-for-%s-%s", transport, state), nil
+}
+
+// WaitFor wait until device is in state or ctx is done, eg: WaitFor(ctx, StateOnline)
+func (d *Device) WaitFor(ctx context.Context, state DeviceState) error {
+	request, err := d.waitForRequest(state)
+	if err != nil {
+		return err
+	}
+	// adb server replies the second OKAY when device is in state
+	conn, err := d.hostCommand(ctx, request)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
 }
 
 // OpenTransport is a low level function
 // Connect to adbd.exe and send <host-prefix>:transport and check OKAY
 // conn should be Close after using
 func (d *Device) OpenTransport() (conn *ADBConn, err error) {
+	return d.OpenTransportContext(context.Background())
+}
+
+// OpenTransportContext is the same as OpenTransport, conn is closed when ctx is done
+func (d *Device) OpenTransportContext(ctx context.Context) (conn *ADBConn, err error) {
 	req := "host:" + d.descriptor.getTransportDescriptor()
-	conn, err = d.client.roundTrip(req)
+	conn, err = d.client.roundTrip(ctx, req)
 	if err != nil {
 		return
 	}
-	conn.CheckOKAY()
-	if conn.Err() != nil {
+	if err = conn.CheckOKAY(); err != nil {
 		conn.Close()
+		return nil, err
 	}
-	return conn, conn.Err()
+	return conn, nil
 }
 
 func (d *Device) OpenShell(cmd string) (rwc io.ReadWriteCloser, err error) {
-	req := "host:" + d.descriptor.getTransportDescriptor()
-	conn, err := d.client.roundTrip(req)
+	return d.OpenShellContext(context.Background(), cmd)
+}
+
+// OpenShellContext run shell command on device, rwc is closed when ctx is done
+func (d *Device) OpenShellContext(ctx context.Context, cmd string) (rwc io.ReadWriteCloser, err error) {
+	conn, err := d.OpenTransportContext(ctx)
 	if err != nil {
 		return
 	}
-	conn.CheckOKAY()
 	conn.EncodeString("shell:" + cmd)
-	conn.CheckOKAY()
-	if conn.Err() != nil {
+	if err = conn.CheckOKAY(); err != nil {
 		conn.Close()
+		return nil, err
 	}
-	return conn, conn.Err()
+	return conn, nil
 }
 
 func (d *Device) RunCommand(args ...string) (output string, err error) {
+	return d.RunCommandContext(context.Background(), args...)
+}
+
+// RunCommandContext returns output of shell command, ctx.Err() is returned if ctx is done before command exit
+func (d *Device) RunCommandContext(ctx context.Context, args ...string) (output string, err error) {
 	cmd := shellquote.Join(args...)
-	rwc, err := d.OpenShell(cmd)
+	rwc, err := d.OpenShellContext(ctx, cmd)
 	if err != nil {
 		return
 	}
+	defer rwc.Close()
 	data, err := ioutil.ReadAll(rwc)
 	if err != nil {
 		return
@@ -72,6 +172,29 @@ func (d *Device) RunCommand(args ...string) (output string, err error) {
 	return string(data), err
 }
 
+// runCommandStatus returns output and exit code of shell command, exit code is printed by
+// the shell after command because shell: service does not return it
+func (d *Device) runCommandStatus(ctx context.Context, args ...string) (output string, code int, err error) {
+	rwc, err := d.OpenShellContext(ctx, shellquote.Join(args...)+"; echo "+exitCodeMarker+"$?")
+	if err != nil {
+		return
+	}
+	defer rwc.Close()
+	data, err := ioutil.ReadAll(rwc)
+	if err != nil {
+		return
+	}
+	output = string(data)
+	i := strings.LastIndex(output, exitCodeMarker)
+	if i < 0 {
+		return output, 0, errors.New("exit code not found in output")
+	}
+	code, err = strconv.Atoi(strings.TrimSpace(output[i+len(exitCodeMarker):]))
+	return output[:i], code, err
+}
+
+const exitCodeMarker = "fa-exit-code:"
+
 // ServeTCP acts as adbd(Daemon) for adb connect
 func (d *Device) ServeTCP(in net.Conn) {
 	NewSession(in, d).Serve() // conn will be Closed inside
@@ -108,15 +231,15 @@ func (f *adbFileInfo) Sys() interface{} {
 }
 
 func (d *Device) Stat(path string) (info os.FileInfo, err error) {
-	req := "host:" + d.descriptor.getTransportDescriptor()
-	conn, err := d.client.roundTrip(req)
+	return d.StatContext(context.Background(), path)
+}
+
+func (d *Device) StatContext(ctx context.Context, path string) (info os.FileInfo, err error) {
+	conn, err := d.OpenTransportContext(ctx)
 	if err != nil {
 		return
 	}
 	defer conn.Close()
-	if err = conn.CheckOKAY(); err != nil {
-		return
-	}
 	conn.EncodeString("sync:")
 	conn.CheckOKAY()
 	conn.WriteObjects("STAT", uint32(len(path)), path)
@@ -151,8 +274,12 @@ func (p PropValue) Bool() bool {
 var propertyRE = regexp.MustCompile(`\[(.+)\]: \[(.+)\]`)
 
 func (ad *Device) Properties() (props map[string]PropValue, err error) {
+	return ad.PropertiesContext(context.Background())
+}
+
+func (ad *Device) PropertiesContext(ctx context.Context) (props map[string]PropValue, err error) {
 	props = make(map[string]PropValue)
-	output, err := ad.RunCommand("getprop")
+	output, err := ad.RunCommandContext(ctx, "getprop")
 	if err != nil {
 		return
 	}
@@ -1,7 +1,10 @@
 package adb
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,3 +16,56 @@ func TestDeviceProperties(t *testing.T) {
 		t.Log(props["ro.product.name"])
 	}
 }
+
+func TestDeviceHostQueries(t *testing.T) {
+	addr, closeFn := fakeHostServer(t, map[string]string{
+		"host-serial:fake:get-serialno":        okayString("fake"),
+		"host-serial:fake:get-state":           okayString("device"),
+		"host-serial:fake:get-devpath":         okayString("usb:1-1"),
+		"host-serial:fake:features":            okayString("shell_v2,cmd,stat_v2"),
+		"host-usb:get-serialno":                okayString("usbdevice"),
+		"host-local:get-state":                 okayString("offline"),
+		"host-serial:fake:wait-for-any-device": "OKAYOKAY",
+		"host-usb:wait-for-usb-disconnect":     "OKAYOKAY",
+		"host:get-state":                       "FAIL001amore than one device/emulator",
+	})
+	defer closeFn()
+	client := NewClient(addr)
+	device := client.DeviceWithSerial("fake")
+
+	serial, err := device.Serial()
+	assert.NoError(t, err)
+	assert.Equal(t, "fake", serial)
+	state, err := device.State()
+	assert.NoError(t, err)
+	assert.Equal(t, StateOnline, state)
+	devpath, err := device.DevPath()
+	assert.NoError(t, err)
+	assert.Equal(t, "usb:1-1", devpath)
+	features, err := device.Features()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shell_v2", "cmd", "stat_v2"}, features)
+	assert.NoError(t, device.WaitFor(context.Background(), StateOnline))
+	assert.Error(t, device.WaitFor(context.Background(), StateUnauthorized))
+
+	serial, err = client.Device(AnyUsbDevice()).Serial()
+	assert.NoError(t, err)
+	assert.Equal(t, "usbdevice", serial)
+	assert.NoError(t, client.Device(AnyUsbDevice()).WaitFor(context.Background(), StateDisconnected))
+	state, err = client.Device(AnyLocalDevice()).State()
+	assert.NoError(t, err)
+	assert.Equal(t, StateOffline, state)
+	_, err = client.Device(AnyDevice()).State()
+	assert.True(t, errors.Is(err, ErrMoreThanOneDevice))
+}
+
+func TestDeviceWaitForCancel(t *testing.T) {
+	addr, closeFn := fakeHostServer(t, map[string]string{
+		"host-serial:fake:wait-for-any-recovery": "OKAY", // device never in recovery
+	})
+	defer closeFn()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := NewClient(addr).DeviceWithSerial("fake").WaitFor(ctx, StateRecovery)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
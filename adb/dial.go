@@ -0,0 +1,80 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// deviceAddr is the address of socket on device
+type deviceAddr struct {
+	device  string
+	address string
+}
+
+func (a deviceAddr) Network() string {
+	return "adb"
+}
+
+func (a deviceAddr) String() string {
+	return a.device + "/" + a.address
+}
+
+// deviceConn reads through ADBConn because bytes may already be buffered after handshake
+type deviceConn struct {
+	net.Conn
+	conn *ADBConn
+	addr deviceAddr
+}
+
+func (c *deviceConn) Read(p []byte) (int, error) {
+	return c.conn.Read(p)
+}
+
+func (c *deviceConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// Dial connect to socket on device without adb forward, address is the same as remote of forward,
+// eg: tcp:8080, localabstract:minicap, localfilesystem:/data/local/tmp/sock.
+// ctx only applies to connecting, conn is not closed when ctx is done after Dial returns
+func (d *Device) Dial(ctx context.Context, address string) (net.Conn, error) {
+	conn, err := d.OpenTransportContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn.EncodeString(address)
+	if err = conn.CheckOKAY(); err == nil {
+		// conn is used after Dial returns, it should not be closed with ctx
+		err = conn.stopWatch()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	nc, ok := conn.Closer.(net.Conn)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("adb connection is not net.Conn")
+	}
+	return &deviceConn{
+		Conn: nc,
+		conn: conn,
+		addr: deviceAddr{device: d.String(), address: address},
+	}, nil
+}
+
+// HTTPTransport returns http.RoundTripper which connects to tcp port on device through Dial,
+// host of request url is ignored, eg: http://device:7912/info is sent to tcp:7912 on device
+func (d *Device) HTTPTransport() *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			return d.Dial(ctx, "tcp:"+port)
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceDial(t *testing.T) {
+	// transport is accepted, tcp:1 is refused like a closed port on device
+	addr, closeFn := fakeServer(t, func(req string, rw *bufio.ReadWriter) {
+		rw.WriteString("OKAY")
+		rw.Flush()
+		service := readRequest(rw)
+		if service == "tcp:1" {
+			rw.WriteString(failString("connection refused"))
+			return
+		}
+		rw.WriteString("OKAY")
+		rw.WriteString("hello " + service + "\n")
+		rw.Flush()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	conn, err := device.Dial(context.Background(), "localabstract:foo")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	line, _ := r.ReadString('\n')
+	assert.Equal(t, "hello localabstract:foo\n", line)
+	conn.Write([]byte("ping\n"))
+	line, _ = r.ReadString('\n')
+	assert.Equal(t, "echo ping\n", line)
+	assert.Equal(t, "adb", conn.RemoteAddr().Network())
+
+	_, err = device.Dial(context.Background(), "tcp:1")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "connection refused")
+	}
+}
+
+func TestDeviceDialContext(t *testing.T) {
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		if service == "tcp:2" {
+			time.Sleep(time.Second) // OKAY of service is delayed
+		}
+		rw.Flush()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	// conn is still usable after ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := device.Dial(ctx, "tcp:7912")
+	cancel()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	conn.Write([]byte("ping\n"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "echo ping\n", line)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = device.Dial(ctx, "tcp:2")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
+
+func TestDeviceHTTPTransport(t *testing.T) {
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		rw.Flush()
+		req, err := http.ReadRequest(rw.Reader)
+		if err != nil {
+			return
+		}
+		body := service + " " + req.URL.Path
+		fmt.Fprintf(rw, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s", len(body), body)
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	client := &http.Client{Transport: device.HTTPTransport()}
+	resp, err := client.Get("http://device:7912/info")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "tcp:7912 /info", string(data))
+}
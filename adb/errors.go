@@ -0,0 +1,67 @@
+package adb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors mapped from adb server messages, check with errors.Is
+var (
+	ErrDeviceNotFound     = errors.New("adb: device not found")
+	ErrDeviceOffline      = errors.New("adb: device offline")
+	ErrUnauthorized       = errors.New("adb: device unauthorized")
+	ErrMoreThanOneDevice  = errors.New("adb: more than one device/emulator")
+	ErrServerNotAvailable = errors.New("adb: server not available")
+)
+
+// ServerError is returned when adb server replies FAIL, Message is the reason sent by server
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return "adb server: " + e.Message
+}
+
+// Is map message to ErrDeviceNotFound, ErrDeviceOffline, ErrUnauthorized or ErrMoreThanOneDevice
+func (e *ServerError) Is(target error) bool {
+	return target != nil && serverMessageError(e.Message) == target
+}
+
+// serverMessageError returns sentinel error of known messages in adb/transport.cpp and adb/services.cpp, eg:
+// device 'xxx' not found, no devices/emulators found, device offline, device unauthorized., more than one device
+func serverMessageError(msg string) error {
+	msg = strings.ToLower(msg)
+	switch {
+	case strings.HasPrefix(msg, "more than one "):
+		return ErrMoreThanOneDevice
+	case strings.HasPrefix(msg, "device unauthorized"), strings.HasPrefix(msg, "device still authorizing"):
+		return ErrUnauthorized
+	case strings.HasPrefix(msg, "device offline"):
+		return ErrDeviceOffline
+	case strings.HasPrefix(msg, "device ") && strings.HasSuffix(msg, " not found"),
+		msg == "device not found",
+		strings.HasPrefix(msg, "no devices"), strings.HasPrefix(msg, "no emulators"):
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+// serverUnavailableError is returned when adb server can not be connected
+type serverUnavailableError struct {
+	addr string
+	err  error
+}
+
+func (e *serverUnavailableError) Error() string {
+	return fmt.Sprintf("adb server %s not available: %v", e.addr, e.err)
+}
+
+func (e *serverUnavailableError) Unwrap() error {
+	return e.err
+}
+
+func (e *serverUnavailableError) Is(target error) bool {
+	return target == ErrServerNotAvailable
+}
@@ -0,0 +1,44 @@
+package adb
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerError(t *testing.T) {
+	for msg, target := range map[string]error{
+		"device 'emulator-5554' not found": ErrDeviceNotFound,
+		"no devices/emulators found":       ErrDeviceNotFound,
+		"device offline":                   ErrDeviceOffline,
+		"device offline (no transport)":    ErrDeviceOffline,
+		"device unauthorized.\nThis adb server's $ADB_VENDOR_KEYS is not set": ErrUnauthorized,
+		"more than one device/emulator":                                       ErrMoreThanOneDevice,
+	} {
+		var err error = &ServerError{Message: msg}
+		assert.True(t, errors.Is(err, target), msg)
+		assert.False(t, errors.Is(err, ErrServerNotAvailable), msg)
+	}
+	err := error(&ServerError{Message: "closed"})
+	assert.False(t, errors.Is(err, ErrDeviceNotFound))
+	var serr *ServerError
+	assert.True(t, errors.As(err, &serr))
+	assert.Equal(t, "closed", serr.Message)
+}
+
+func TestCheckOKAYServerError(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	go func() {
+		server.Write([]byte("FAIL0017device 'fake' not found"))
+		server.Close()
+	}()
+	err := NewADBConn(client).CheckOKAY()
+	assert.True(t, errors.Is(err, ErrDeviceNotFound))
+	var serr *ServerError
+	if assert.True(t, errors.As(err, &serr)) {
+		assert.Equal(t, "device 'fake' not found", serr.Message)
+	}
+}
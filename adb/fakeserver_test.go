@@ -0,0 +1,90 @@
+package adb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+)
+
+// readRequest read hex length prefixed request sent by client
+func readRequest(r io.Reader) string {
+	var length int
+	hexlen := make([]byte, 4)
+	io.ReadFull(r, hexlen)
+	fmt.Sscanf(string(hexlen), "%04x", &length)
+	data := make([]byte, length)
+	io.ReadFull(r, data)
+	return string(data)
+}
+
+func okayString(s string) string {
+	return fmt.Sprintf("OKAY%04x%s", len(s), s)
+}
+
+func failString(s string) string {
+	return fmt.Sprintf("FAIL%04x%s", len(s), s)
+}
+
+// fakeServer listen on random port like adb server, handle is called with the first request of
+// each connection, rw is flushed and connection is closed after handle returns
+func fakeServer(t *testing.T, handle func(req string, rw *bufio.ReadWriter)) (addr string, closeFn func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+				handle(readRequest(rw), rw)
+				rw.Flush()
+			}()
+		}
+	}()
+	return ln.Addr().String(), func() { ln.Close() }
+}
+
+// fakeDeviceServer reply requests with host(req), FAIL is replied to unknown host request.
+// host:transport requests not replied by host are replied OKAY, then service is passed to serve
+func fakeDeviceServer(t *testing.T, host func(req string) string, serve func(service string, rw *bufio.ReadWriter)) (addr string, closeFn func()) {
+	return fakeServer(t, func(req string, rw *bufio.ReadWriter) {
+		reply := ""
+		if host != nil {
+			reply = host(req)
+		}
+		if reply == "" && serve != nil && strings.HasPrefix(req, "host:transport") {
+			rw.WriteString("OKAY")
+			rw.Flush()
+			service := readRequest(rw)
+			// OKAY is flushed by serve, so data after OKAY may arrive in the same packet
+			rw.WriteString("OKAY")
+			serve(service, rw)
+			return
+		}
+		if reply == "" {
+			reply = failString("unknown request " + req)
+		}
+		rw.WriteString(reply)
+		rw.Flush()
+		io.Copy(ioutil.Discard, rw) // keep open until client closed
+	})
+}
+
+// fakeHostServer reply host requests with replies, FAIL is replied to unknown request
+func fakeHostServer(t *testing.T, replies map[string]string) (addr string, closeFn func()) {
+	return fakeDeviceServer(t, func(req string) string { return replies[req] }, nil)
+}
+
+// fakeTransportServer accept transport request and service, then pass the connection to serve
+func fakeTransportServer(t *testing.T, serve func(service string, rw *bufio.ReadWriter)) (addr string, closeFn func()) {
+	return fakeDeviceServer(t, nil, serve)
+}
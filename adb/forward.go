@@ -0,0 +1,241 @@
+package adb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Forward is a forward or reverse rule, Local is on host side and Remote is on device side
+type Forward struct {
+	Serial string `json:"serial"`
+	Local  string `json:"local"`
+	Remote string `json:"remote"`
+}
+
+func parseForwards(lines string) []Forward {
+	forwards := make([]Forward, 0)
+	for _, line := range strings.Split(lines, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		forwards = append(forwards, Forward{Serial: fields[0], Local: fields[1], Remote: fields[2]})
+	}
+	return forwards
+}
+
+// readResolvedPort read port allocated for tcp:0, which is replied by adb 1.0.36+
+func readResolvedPort(conn *ADBConn, spec string) (port int, err error) {
+	if spec == "tcp:0" {
+		resp, err := conn.DecodeString()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(strings.TrimSpace(resp))
+	}
+	if strings.HasPrefix(spec, "tcp:") {
+		port, _ = strconv.Atoi(strings.TrimPrefix(spec, "tcp:"))
+	}
+	return port, nil
+}
+
+// ListForwards returns forwards of all devices
+func (c *Client) ListForwards() ([]Forward, error) {
+	return c.ListForwardsContext(context.Background())
+}
+
+func (c *Client) ListForwardsContext(ctx context.Context) ([]Forward, error) {
+	lines, err := c.roundTripSingleResponse(ctx, "host:list-forward")
+	if err != nil {
+		return nil, err
+	}
+	return parseForwards(lines), nil
+}
+
+// RemoveAllForwards remove forwards of all devices
+func (c *Client) RemoveAllForwards() error {
+	return c.RemoveAllForwardsContext(context.Background())
+}
+
+func (c *Client) RemoveAllForwardsContext(ctx context.Context) error {
+	conn, err := c.roundTrip(ctx, "host:killforward-all")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.CheckOKAY()
+}
+
+// resolveSerial returns serial of device, query adb server if device is not selected by serial
+func (d *Device) resolveSerial(ctx context.Context) (string, error) {
+	if d.descriptor.descriptorType == DeviceSerial {
+		return d.descriptor.serial, nil
+	}
+	return d.SerialContext(ctx)
+}
+
+// hostCommand send host request for device, the first OKAY is for host and the second for device
+func (d *Device) hostCommand(ctx context.Context, service string) (conn *ADBConn, err error) {
+	conn, err = d.client.roundTrip(ctx, d.descriptor.getHostPrefix()+":"+service)
+	if err != nil {
+		return
+	}
+	if err = conn.CheckOKAY(); err == nil {
+		err = conn.CheckOKAY()
+	}
+	if err != nil {
+		conn.Close()
+	}
+	return
+}
+
+// Forward forward local socket on host to remote socket on device.
+// eg: Forward("tcp:7912", "tcp:7912"), Forward("tcp:0", "localabstract:minicap").
+// port is the local tcp port, which is allocated by adb server when local is tcp:0
+func (d *Device) Forward(local, remote string) (port int, err error) {
+	return d.ForwardContext(context.Background(), local, remote)
+}
+
+func (d *Device) ForwardContext(ctx context.Context, local, remote string) (port int, err error) {
+	conn, err := d.hostCommand(ctx, "forward:"+local+";"+remote)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	return readResolvedPort(conn, local)
+}
+
+// ListForwards returns forwards of device
+func (d *Device) ListForwards() ([]Forward, error) {
+	return d.ListForwardsContext(context.Background())
+}
+
+func (d *Device) ListForwardsContext(ctx context.Context) ([]Forward, error) {
+	serial, err := d.resolveSerial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	forwards, err := d.client.ListForwardsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Forward, 0, len(forwards))
+	for _, f := range forwards {
+		if f.Serial == serial {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// RemoveForward remove forward of local socket
+func (d *Device) RemoveForward(local string) error {
+	return d.RemoveForwardContext(context.Background(), local)
+}
+
+func (d *Device) RemoveForwardContext(ctx context.Context, local string) error {
+	conn, err := d.hostCommand(ctx, "killforward:"+local)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// RemoveAllForwards remove forwards of device, forwards of other devices are kept
+func (d *Device) RemoveAllForwards() error {
+	return d.RemoveAllForwardsContext(context.Background())
+}
+
+func (d *Device) RemoveAllForwardsContext(ctx context.Context) error {
+	forwards, err := d.ListForwardsContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, f := range forwards {
+		if err := d.RemoveForwardContext(ctx, f.Local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reverseCommand open reverse service on device, the reverse result is checked by another OKAY
+func (d *Device) reverseCommand(ctx context.Context, service string, checkOKAY bool) (conn *ADBConn, err error) {
+	conn, err = d.OpenTransportContext(ctx)
+	if err != nil {
+		return
+	}
+	conn.EncodeString("reverse:" + service)
+	if err = conn.CheckOKAY(); err == nil && checkOKAY {
+		err = conn.CheckOKAY()
+	}
+	if err != nil {
+		conn.Close()
+	}
+	return
+}
+
+// Reverse forward remote socket on device to local socket on host.
+// port is the remote tcp port, which is allocated by device when remote is tcp:0
+func (d *Device) Reverse(remote, local string) (port int, err error) {
+	return d.ReverseContext(context.Background(), remote, local)
+}
+
+func (d *Device) ReverseContext(ctx context.Context, remote, local string) (port int, err error) {
+	conn, err := d.reverseCommand(ctx, "forward:"+remote+";"+local, true)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	return readResolvedPort(conn, remote)
+}
+
+// ListReverses returns reverses of device, Forward.Remote is the socket on device
+func (d *Device) ListReverses() ([]Forward, error) {
+	return d.ListReversesContext(context.Background())
+}
+
+func (d *Device) ListReversesContext(ctx context.Context) ([]Forward, error) {
+	conn, err := d.reverseCommand(ctx, "list-forward", false)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	lines, err := conn.DecodeString()
+	if err != nil {
+		return nil, err
+	}
+	// device reports "<transport> <remote> <local>"
+	reverses := parseForwards(lines)
+	for i, r := range reverses {
+		reverses[i].Local, reverses[i].Remote = r.Remote, r.Local
+	}
+	return reverses, nil
+}
+
+// RemoveReverse remove reverse of remote socket
+func (d *Device) RemoveReverse(remote string) error {
+	return d.RemoveReverseContext(context.Background(), remote)
+}
+
+func (d *Device) RemoveReverseContext(ctx context.Context, remote string) error {
+	conn, err := d.reverseCommand(ctx, "killforward:"+remote, true)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// RemoveAllReverses remove all reverses of device
+func (d *Device) RemoveAllReverses() error {
+	return d.RemoveAllReversesContext(context.Background())
+}
+
+func (d *Device) RemoveAllReversesContext(ctx context.Context) error {
+	conn, err := d.reverseCommand(ctx, "killforward-all", true)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,16 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseForwards(t *testing.T) {
+	forwards := parseForwards("0123456789ABCDEF tcp:7912 tcp:7912\nemulator-5554 tcp:37211 localabstract:minicap\n")
+	assert.Equal(t, []Forward{
+		{Serial: "0123456789ABCDEF", Local: "tcp:7912", Remote: "tcp:7912"},
+		{Serial: "emulator-5554", Local: "tcp:37211", Remote: "localabstract:minicap"},
+	}, forwards)
+	assert.Len(t, parseForwards(""), 0)
+}
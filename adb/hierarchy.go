@@ -0,0 +1,206 @@
+package adb
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UINode is a view node of uiautomator dump
+type UINode struct {
+	Index       int             `json:"index"`
+	Text        string          `json:"text"`
+	ResourceID  string          `json:"resourceId"`
+	Class       string          `json:"class"`
+	Package     string          `json:"package"`
+	ContentDesc string          `json:"contentDesc"`
+	Clickable   bool            `json:"clickable"`
+	Enabled     bool            `json:"enabled"`
+	Focused     bool            `json:"focused"`
+	Checked     bool            `json:"checked"`
+	Selected    bool            `json:"selected"`
+	Scrollable  bool            `json:"scrollable"`
+	Bounds      image.Rectangle `json:"bounds"`
+	Children    []*UINode       `json:"children,omitempty"`
+}
+
+// Center returns center point of node bounds
+func (n *UINode) Center() image.Point {
+	return image.Pt((n.Bounds.Min.X+n.Bounds.Max.X)/2, (n.Bounds.Min.Y+n.Bounds.Max.Y)/2)
+}
+
+// Walk visit node and all descendants in depth-first order, stop when fn returns false
+func (n *UINode) Walk(fn func(node *UINode, depth int) bool) {
+	n.walk(fn, 0)
+}
+
+func (n *UINode) walk(fn func(node *UINode, depth int) bool, depth int) bool {
+	if !fn(n, depth) {
+		return false
+	}
+	for _, c := range n.Children {
+		if !c.walk(fn, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// UISelector match nodes by attributes, empty fields are ignored
+type UISelector struct {
+	Text         string
+	TextContains string
+	ResourceID   string
+	Class        string
+	ContentDesc  string
+}
+
+func (s UISelector) Match(n *UINode) bool {
+	if s.Text != "" && n.Text != s.Text {
+		return false
+	}
+	if s.TextContains != "" && !strings.Contains(n.Text, s.TextContains) {
+		return false
+	}
+	if s.ResourceID != "" && n.ResourceID != s.ResourceID && !strings.HasSuffix(n.ResourceID, ":id/"+s.ResourceID) {
+		return false
+	}
+	if s.Class != "" && n.Class != s.Class && !strings.HasSuffix(n.Class, "."+s.Class) {
+		return false
+	}
+	if s.ContentDesc != "" && n.ContentDesc != s.ContentDesc {
+		return false
+	}
+	return true
+}
+
+// Find returns nodes matched by selector
+func (n *UINode) Find(s UISelector) []*UINode {
+	nodes := make([]*UINode, 0)
+	n.Walk(func(node *UINode, depth int) bool {
+		if node != n || n.Class != "" { // skip the virtual hierarchy root
+			if s.Match(node) {
+				nodes = append(nodes, node)
+			}
+		}
+		return true
+	})
+	return nodes
+}
+
+type xmlNode struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Nodes []xmlNode  `xml:"node"`
+}
+
+var boundsRE = regexp.MustCompile(`^\[(-?\d+),(-?\d+)\]\[(-?\d+),(-?\d+)\]$`)
+
+func parseBounds(s string) image.Rectangle {
+	m := boundsRE.FindStringSubmatch(s)
+	if m == nil {
+		return image.Rectangle{}
+	}
+	v := make([]int, 4)
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	return image.Rect(v[0], v[1], v[2], v[3])
+}
+
+func (x xmlNode) toUINode() *UINode {
+	n := &UINode{}
+	for _, attr := range x.Attrs {
+		boolValue := attr.Value == "true"
+		switch attr.Name.Local {
+		case "index":
+			n.Index, _ = strconv.Atoi(attr.Value)
+		case "text":
+			n.Text = attr.Value
+		case "resource-id":
+			n.ResourceID = attr.Value
+		case "class":
+			n.Class = attr.Value
+		case "package":
+			n.Package = attr.Value
+		case "content-desc":
+			n.ContentDesc = attr.Value
+		case "clickable":
+			n.Clickable = boolValue
+		case "enabled":
+			n.Enabled = boolValue
+		case "focused":
+			n.Focused = boolValue
+		case "checked":
+			n.Checked = boolValue
+		case "selected":
+			n.Selected = boolValue
+		case "scrollable":
+			n.Scrollable = boolValue
+		case "bounds":
+			n.Bounds = parseBounds(attr.Value)
+		}
+	}
+	for _, c := range x.Nodes {
+		n.Children = append(n.Children, c.toUINode())
+	}
+	return n
+}
+
+// ParseHierarchy parse xml of uiautomator dump, returned root node is the <hierarchy> element
+func ParseHierarchy(data []byte) (root *UINode, err error) {
+	var x xmlNode
+	if err = xml.Unmarshal(data, &x); err != nil {
+		return
+	}
+	return x.toUINode(), nil
+}
+
+// DumpHierarchyXML returns raw xml of uiautomator dump
+func (d *Device) DumpHierarchyXML() (data []byte, err error) {
+	return d.DumpHierarchyXMLContext(context.Background())
+}
+
+func (d *Device) DumpHierarchyXMLContext(ctx context.Context) (data []byte, err error) {
+	// unique path, so concurrent dumps on the same device do not read each other's file
+	dumpPath := fmt.Sprintf("/data/local/tmp/fa-window-dump-%d.xml", time.Now().UnixNano())
+	output, err := d.RunCommandContext(ctx, "uiautomator", "dump", dumpPath)
+	if err != nil {
+		return
+	}
+	// removed even if ctx is done
+	defer d.RunCommand("rm", "-f", dumpPath)
+	// output example: UI hierchary dumped to: /data/local/tmp/fa-window-dump-1571234567890.xml
+	if !strings.Contains(output, "dumped to") {
+		return nil, fmt.Errorf("uiautomator dump: %s", strings.TrimSpace(output))
+	}
+	rc, err := d.OpenReadContext(ctx, dumpPath)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	data, err = ioutil.ReadAll(rc)
+	if err == nil && len(data) == 0 {
+		err = errors.New("uiautomator dump: empty output")
+	}
+	return
+}
+
+// DumpHierarchy returns view tree of current screen
+func (d *Device) DumpHierarchy() (root *UINode, err error) {
+	return d.DumpHierarchyContext(context.Background())
+}
+
+func (d *Device) DumpHierarchyContext(ctx context.Context) (root *UINode, err error) {
+	data, err := d.DumpHierarchyXMLContext(ctx)
+	if err != nil {
+		return
+	}
+	return ParseHierarchy(data)
+}
@@ -0,0 +1,37 @@
+package adb
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testHierarchy = `<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>
+<hierarchy rotation="0">
+  <node index="0" text="" resource-id="" class="android.widget.FrameLayout" package="com.android.settings" content-desc="" clickable="false" enabled="true" bounds="[0,0][1080,1920]">
+    <node index="0" text="Cancel" resource-id="android:id/button2" class="android.widget.Button" package="com.android.settings" content-desc="" clickable="true" enabled="true" bounds="[100,1600][500,1700]" />
+    <node index="1" text="OK" resource-id="android:id/button1" class="android.widget.Button" package="com.android.settings" content-desc="" clickable="true" enabled="true" bounds="[580,1600][980,1700]" />
+  </node>
+</hierarchy>`
+
+func TestParseHierarchy(t *testing.T) {
+	root, err := ParseHierarchy([]byte(testHierarchy))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, root.Children, 1)
+	frame := root.Children[0]
+	assert.Equal(t, "android.widget.FrameLayout", frame.Class)
+	assert.Equal(t, image.Rect(0, 0, 1080, 1920), frame.Bounds)
+
+	nodes := root.Find(UISelector{Text: "OK"})
+	if assert.Len(t, nodes, 1) {
+		assert.True(t, nodes[0].Clickable)
+		assert.Equal(t, image.Pt(780, 1650), nodes[0].Center())
+	}
+	assert.Len(t, root.Find(UISelector{ResourceID: "button2"}), 1)
+	assert.Len(t, root.Find(UISelector{Class: "Button"}), 2)
+	assert.Len(t, root.Find(UISelector{Class: "Button", TextContains: "Can"}), 1)
+	assert.Len(t, root.Find(UISelector{Text: "Nothing"}), 0)
+}
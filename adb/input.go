@@ -0,0 +1,152 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Tap click at (x, y)
+func (d *Device) Tap(x, y int) error {
+	return d.TapContext(context.Background(), x, y)
+}
+
+func (d *Device) TapContext(ctx context.Context, x, y int) error {
+	return d.runInput(ctx, "tap", strconv.Itoa(x), strconv.Itoa(y))
+}
+
+// Swipe from (x1, y1) to (x2, y2) in duration
+func (d *Device) Swipe(x1, y1, x2, y2 int, duration time.Duration) error {
+	return d.SwipeContext(context.Background(), x1, y1, x2, y2, duration)
+}
+
+func (d *Device) SwipeContext(ctx context.Context, x1, y1, x2, y2 int, duration time.Duration) error {
+	return d.runInput(ctx, "swipe", strconv.Itoa(x1), strconv.Itoa(y1), strconv.Itoa(x2), strconv.Itoa(y2),
+		strconv.Itoa(int(duration/time.Millisecond)))
+}
+
+// LongPress touch (x, y) for duration, default to 1s if duration is 0
+func (d *Device) LongPress(x, y int, duration time.Duration) error {
+	return d.LongPressContext(context.Background(), x, y, duration)
+}
+
+func (d *Device) LongPressContext(ctx context.Context, x, y int, duration time.Duration) error {
+	if duration == 0 {
+		duration = time.Second
+	}
+	return d.SwipeContext(ctx, x, y, x, y, duration)
+}
+
+// KeyEvent send key events, code can be number or name, eg: 3, HOME, KEYCODE_HOME
+func (d *Device) KeyEvent(codes ...string) error {
+	return d.KeyEventContext(context.Background(), codes...)
+}
+
+func (d *Device) KeyEventContext(ctx context.Context, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	args := []string{"keyevent"}
+	for _, code := range codes {
+		args = append(args, normalizeKeyCode(code))
+	}
+	return d.runInput(ctx, args...)
+}
+
+// old Android only accept KEYCODE_ prefixed name
+func normalizeKeyCode(code string) string {
+	if _, err := strconv.Atoi(code); err == nil {
+		return code
+	}
+	code = strings.ToUpper(code)
+	if !strings.HasPrefix(code, "KEYCODE_") {
+		code = "KEYCODE_" + code
+	}
+	return code
+}
+
+// Text type text into focused view. Only ASCII is supported by input text,
+// lines are separated by KEYCODE_ENTER
+func (d *Device) Text(s string) error {
+	return d.TextContext(context.Background(), s)
+}
+
+func (d *Device) TextContext(ctx context.Context, s string) error {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return errors.New("input text only support ASCII characters")
+		}
+	}
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			if err := d.KeyEventContext(ctx, "ENTER"); err != nil {
+				return err
+			}
+		}
+		if line == "" {
+			continue
+		}
+		for _, chunk := range splitInputText(line) {
+			output, err := d.shell(ctx, "input text "+escapeInputText(chunk))
+			if err != nil {
+				return err
+			}
+			if err := checkInputOutput("text", output); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitInputText split s after % which is followed by s, because input text always
+// types %s as space and has no way to escape it
+func splitInputText(s string) (chunks []string) {
+	for {
+		i := strings.Index(s, "%s")
+		if i < 0 {
+			break
+		}
+		chunks = append(chunks, s[:i+1])
+		s = s[i+1:]
+	}
+	return append(chunks, s)
+}
+
+// escapeInputText convert spaces to %s, which input text types as space, and escape
+// other characters with backslash, so s is typed as is after parsed by device shell
+func escapeInputText(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		switch {
+		case r == ' ' || r == '\t':
+			buf.WriteString("%s")
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("%,._+-=:/@", r):
+			buf.WriteRune(r)
+		default:
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+func (d *Device) runInput(ctx context.Context, args ...string) error {
+	output, err := d.RunCommandContext(ctx, append([]string{"input"}, args...)...)
+	if err != nil {
+		return err
+	}
+	return checkInputOutput(args[0], output)
+}
+
+// checkInputOutput check output of input, which prints usage or exception when arguments are invalid
+func checkInputOutput(name, output string) error {
+	lower := strings.ToLower(output)
+	if strings.Contains(lower, "exception") || strings.Contains(lower, "error") || strings.Contains(lower, "usage:") {
+		return errors.New("input " + name + ": " + strings.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeInputText(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		expect string
+	}{
+		{"hello world", "hello%sworld"},
+		{"a\tb", "a%sb"},
+		{"user@example.com", "user@example.com"},
+		{"50% off", "50%%soff"},
+		{"it's", `it\'s`},
+		{`say "hi"`, `say%s\"hi\"`},
+		{"a&b;c|d", `a\&b\;c\|d`},
+		{"(x)<y>", `\(x\)\<y\>`},
+		{`C:\dir`, `C:\\dir`},
+		{"$HOME`id`", "\\$HOME\\`id\\`"},
+		{"*?#~!", `\*\?\#\~\!`},
+	} {
+		assert.Equal(t, tc.expect, escapeInputText(tc.text), tc.text)
+	}
+}
+
+func TestSplitInputText(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		expect []string
+	}{
+		{"hello", []string{"hello"}},
+		{"50% off", []string{"50% off"}},
+		{"%s", []string{"%", "s"}},
+		{"a%sb%sc", []string{"a%", "sb%", "sc"}},
+	} {
+		assert.Equal(t, tc.expect, splitInputText(tc.text), tc.text)
+	}
+}
+
+func TestNormalizeKeyCode(t *testing.T) {
+	assert.Equal(t, "3", normalizeKeyCode("3"))
+	assert.Equal(t, "KEYCODE_HOME", normalizeKeyCode("home"))
+	assert.Equal(t, "KEYCODE_BACK", normalizeKeyCode("KEYCODE_BACK"))
+}
@@ -0,0 +1,163 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+type RebootMode string
+
+// bootPollInterval is the interval to check device state and sys.boot_completed
+var bootPollInterval = time.Second
+
+const (
+	RebootNormal     = RebootMode("")
+	RebootBootloader = RebootMode("bootloader")
+	RebootRecovery   = RebootMode("recovery")
+	RebootSideload   = RebootMode("sideload")
+	RebootFastboot   = RebootMode("fastboot") // userspace fastbootd, Android 10+
+)
+
+// serviceOutput open service on device and returns output until adbd close the connection
+func (d *Device) serviceOutput(ctx context.Context, service string) (string, error) {
+	conn, err := d.openService(ctx, service)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	data, err := ioutil.ReadAll(conn)
+	return strings.TrimSpace(string(data)), err
+}
+
+// Reboot reboot device into mode through reboot: service
+func (d *Device) Reboot(mode RebootMode) error {
+	return d.RebootContext(context.Background(), mode)
+}
+
+func (d *Device) RebootContext(ctx context.Context, mode RebootMode) error {
+	switch mode {
+	case RebootNormal, RebootBootloader, RebootRecovery, RebootSideload, RebootFastboot:
+	default:
+		return fmt.Errorf("unknown reboot mode %q", mode)
+	}
+	conn, err := d.openService(ctx, "reboot:"+string(mode))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// connection is dropped when device is going down, read error is expected
+	ioutil.ReadAll(conn)
+	return ctx.Err()
+}
+
+// Root restart adbd as root, error is returned on production builds
+func (d *Device) Root() error {
+	return d.RootContext(context.Background())
+}
+
+func (d *Device) RootContext(ctx context.Context) error {
+	output, err := d.serviceOutput(ctx, "root:")
+	if err != nil {
+		return err
+	}
+	// replies of restart_root_service in adbd, eg: adbd cannot run as root in production builds
+	switch output {
+	case "restarting adbd as root", "adbd is already running as root":
+		return nil
+	}
+	return errors.New("root: " + output)
+}
+
+// Unroot restart adbd as non root
+func (d *Device) Unroot() error {
+	return d.UnrootContext(context.Background())
+}
+
+func (d *Device) UnrootContext(ctx context.Context) error {
+	output, err := d.serviceOutput(ctx, "unroot:")
+	if err != nil {
+		return err
+	}
+	// replies of restart_unroot_service in adbd
+	switch output {
+	case "restarting adbd as non root", "adbd not running as root":
+		return nil
+	}
+	return errors.New("unroot: " + output)
+}
+
+// Remount remount /system, /vendor etc as writable, adbd should be running as root
+func (d *Device) Remount() error {
+	return d.RemountContext(context.Background())
+}
+
+func (d *Device) RemountContext(ctx context.Context) error {
+	output, err := d.serviceOutput(ctx, "remount:")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(strings.ToLower(output), "remount succeeded") {
+		return errors.New("remount: " + output)
+	}
+	return nil
+}
+
+// isPermanentError returns true if err does not go away while device is booting
+func isPermanentError(err error) bool {
+	return errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrMoreThanOneDevice) || errors.Is(err, ErrServerNotAvailable)
+}
+
+// WaitBootCompleted wait until device is online and sys.boot_completed is 1.
+// Call it after Reboot to wait for the device to go down and boot up again
+func (d *Device) WaitBootCompleted(ctx context.Context) error {
+	if err := d.WaitFor(ctx, StateOnline); err != nil {
+		return err
+	}
+	for {
+		props, err := d.PropertiesContext(ctx)
+		if err == nil && props["sys.boot_completed"] == "1" {
+			return nil
+		}
+		if err != nil && isPermanentError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(bootPollInterval):
+		}
+	}
+}
+
+// waitDown wait until device is not online, so boot_completed of the last boot is not seen
+func (d *Device) waitDown(ctx context.Context) error {
+	for {
+		state, err := d.StateContext(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && isPermanentError(err) {
+			return err
+		}
+		if err != nil || state != StateOnline {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(bootPollInterval / 2):
+		}
+	}
+}
+
+// WaitRebootCompleted wait until device goes down and boot completed again, call it after Reboot
+func (d *Device) WaitRebootCompleted(ctx context.Context) error {
+	if err := d.waitDown(ctx); err != nil {
+		return err
+	}
+	return d.WaitBootCompleted(ctx)
+}
@@ -0,0 +1,136 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceReboot(t *testing.T) {
+	services := make(chan string, 10)
+	outputs := map[string]string{
+		"root:":    "adbd cannot run as root in production builds\n",
+		"unroot:":  "restarting adbd as non root\n",
+		"remount:": "remount succeeded\n",
+	}
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		services <- service
+		rw.WriteString(outputs[service])
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	assert.NoError(t, device.Reboot(RebootRecovery))
+	assert.Equal(t, "reboot:recovery", <-services)
+	assert.NoError(t, device.Reboot(RebootNormal))
+	assert.Equal(t, "reboot:", <-services)
+	assert.Error(t, device.Reboot(RebootMode("download")))
+
+	assert.Error(t, device.Root())
+	assert.Equal(t, "root:", <-services)
+	assert.NoError(t, device.Unroot())
+	assert.Equal(t, "unroot:", <-services)
+	assert.NoError(t, device.Remount())
+	assert.Equal(t, "remount:", <-services)
+}
+
+func TestDeviceRootReplies(t *testing.T) {
+	replies := make(chan string, 1)
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		rw.WriteString(<-replies)
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	for _, tc := range []struct {
+		root   bool
+		reply  string
+		failed bool
+	}{
+		{true, "restarting adbd as root\n", false},
+		{true, "adbd is already running as root\n", false},
+		{true, "adbd cannot run as root in production builds\n", true},
+		{true, "", true},
+		{false, "restarting adbd as non root\n", false},
+		{false, "adbd not running as root\n", false},
+		{false, "adbd cannot run as root in production builds\n", true},
+	} {
+		replies <- tc.reply
+		var err error
+		if tc.root {
+			err = device.Root()
+		} else {
+			err = device.Unroot()
+		}
+		assert.Equal(t, tc.failed, err != nil, "root=%v %q: %v", tc.root, tc.reply, err)
+	}
+}
+
+func TestDeviceWaitRebootCompleted(t *testing.T) {
+	defer func(interval time.Duration) { bootPollInterval = interval }(bootPollInterval)
+	bootPollInterval = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	var requests []string
+	states := []string{"device", "offline", "device"}
+	bootCompleted := []string{"0", "1"}
+	addr, closeFn := fakeDeviceServer(t, func(req string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch req {
+		case "host-serial:fake:get-state":
+			state := states[0]
+			if len(states) > 1 {
+				states = states[1:]
+			}
+			requests = append(requests, state)
+			return okayString(state)
+		case "host-serial:fake:wait-for-any-device":
+			requests = append(requests, "wait-for-device")
+			return "OKAYOKAY"
+		}
+		return ""
+	}, func(service string, rw *bufio.ReadWriter) {
+		mu.Lock()
+		defer mu.Unlock()
+		if service != "shell:getprop" {
+			return
+		}
+		requests = append(requests, "boot_completed="+bootCompleted[0])
+		rw.WriteString("[sys.boot_completed]: [" + bootCompleted[0] + "]\n")
+		rw.Flush()
+		if len(bootCompleted) > 1 {
+			bootCompleted = bootCompleted[1:]
+		}
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, device.WaitRebootCompleted(ctx))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"device", "offline", "wait-for-device", "boot_completed=0", "boot_completed=1"}, requests)
+}
+
+func TestDeviceWaitBootCompletedUnauthorized(t *testing.T) {
+	addr, closeFn := fakeHostServer(t, map[string]string{
+		"host-serial:fake:wait-for-any-device": "OKAYOKAY",
+		"host:transport:fake":                  failString("device unauthorized.\nPlease check the confirmation dialog on your device."),
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := device.WaitBootCompleted(ctx)
+	assert.True(t, errors.Is(err, ErrUnauthorized), "%v", err)
+}
@@ -0,0 +1,148 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// shell run cmd in device shell without quoting, so cmd can contains ; && etc.
+func (d *Device) shell(ctx context.Context, cmd string) (output string, err error) {
+	rwc, err := d.OpenShellContext(ctx, cmd)
+	if err != nil {
+		return
+	}
+	defer rwc.Close()
+	data, err := ioutil.ReadAll(rwc)
+	return string(data), err
+}
+
+var screenOnRE = regexp.MustCompile(`(?:mWakefulness=|Display Power: state=|mScreenOn=)(\w+)`)
+
+// IsScreenOn check screen state through dumpsys power
+func (d *Device) IsScreenOn() (bool, error) {
+	return d.IsScreenOnContext(context.Background())
+}
+
+func (d *Device) IsScreenOnContext(ctx context.Context) (bool, error) {
+	output, err := d.RunCommandContext(ctx, "dumpsys", "power")
+	if err != nil {
+		return false, err
+	}
+	m := screenOnRE.FindStringSubmatch(output)
+	if m == nil {
+		return false, fmt.Errorf("screen state not found in dumpsys power")
+	}
+	switch m[1] {
+	case "Awake", "ON", "true":
+		return true, nil
+	}
+	return false, nil
+}
+
+// keyguard fields differ between Android versions
+var keyguardRE = regexp.MustCompile(`(?:mShowingLockscreen|mDreamingLockscreen|isStatusBarKeyguard|mKeyguardShowing)=(true|false)`)
+
+// parseKeyguardShowing returns showing=true if any keyguard field is true
+func parseKeyguardShowing(output string) (showing bool, found bool) {
+	for _, m := range keyguardRE.FindAllStringSubmatch(output, -1) {
+		found = true
+		if m[1] == "true" {
+			return true, true
+		}
+	}
+	return false, found
+}
+
+// IsLocked check whether keyguard is showing
+func (d *Device) IsLocked() (bool, error) {
+	return d.IsLockedContext(context.Background())
+}
+
+func (d *Device) IsLockedContext(ctx context.Context) (bool, error) {
+	found := false
+	for _, args := range [][]string{
+		{"dumpsys", "window", "policy"},
+		{"dumpsys", "window"},
+		{"dumpsys", "activity", "activities"}, // KeyguardController on Android 10+
+	} {
+		output, err := d.RunCommandContext(ctx, args...)
+		if err != nil {
+			return false, err
+		}
+		showing, ok := parseKeyguardShowing(output)
+		if showing {
+			return true, nil
+		}
+		found = found || ok
+	}
+	if !found {
+		return false, fmt.Errorf("keyguard state not found in dumpsys window")
+	}
+	return false, nil
+}
+
+// WakeUp turn on screen if it is off
+func (d *Device) WakeUp() error {
+	return d.WakeUpContext(context.Background())
+}
+
+func (d *Device) WakeUpContext(ctx context.Context) error {
+	on, err := d.IsScreenOnContext(ctx)
+	if err != nil || on {
+		return err
+	}
+	// KEYCODE_WAKEUP is available since Android 4.4W, POWER toggles screen state
+	if err := d.KeyEventContext(ctx, "WAKEUP"); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(500 * time.Millisecond):
+	}
+	if on, _ = d.IsScreenOnContext(ctx); !on {
+		return d.KeyEventContext(ctx, "POWER")
+	}
+	return nil
+}
+
+// Gesture touch down at the first point, move through points and touch up at the last point.
+// Require input motionevent, which is available since Android 9
+func (d *Device) Gesture(points []image.Point, interval time.Duration) error {
+	return d.GestureContext(context.Background(), points, interval)
+}
+
+func (d *Device) GestureContext(ctx context.Context, points []image.Point, interval time.Duration) error {
+	if len(points) == 0 {
+		return nil
+	}
+	sleep := fmt.Sprintf("sleep %.3f", interval.Seconds())
+	cmds := make([]string, 0, len(points)*2+2)
+	for i, p := range points {
+		action := "MOVE"
+		if i == 0 {
+			action = "DOWN"
+		}
+		cmds = append(cmds, fmt.Sprintf("input motionevent %s %d %d", action, p.X, p.Y), sleep)
+	}
+	last := points[len(points)-1]
+	cmds = append(cmds, fmt.Sprintf("input motionevent UP %d %d", last.X, last.Y))
+	output, err := d.shell(ctx, strings.Join(cmds, "; "))
+	if err != nil {
+		return err
+	}
+	if strings.Contains(output, "Unknown command") || strings.Contains(output, "Usage:") {
+		// touch up is not available through input swipe, so the gesture can not be emulated
+		return errors.New("input motionevent not supported, gesture require Android 9+")
+	}
+	if strings.Contains(strings.ToLower(output), "error") {
+		return fmt.Errorf("input motionevent: %s", strings.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package adb
+
+import (
+	"bufio"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyguardShowing(t *testing.T) {
+	showing, found := parseKeyguardShowing("    mShowingLockscreen=true mShowingDream=false mDreamingLockscreen=true")
+	assert.True(t, found)
+	assert.True(t, showing)
+
+	showing, found = parseKeyguardShowing("  KeyguardController:\n    mKeyguardShowing=false\n    mAodShowing=false")
+	assert.True(t, found)
+	assert.False(t, showing)
+
+	_, found = parseKeyguardShowing("mCurrentFocus=null")
+	assert.False(t, found)
+}
+
+func TestDeviceGesture(t *testing.T) {
+	cmds := make(chan string, 2)
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		cmds <- service
+		if strings.Contains(service, "DOWN 1 2") {
+			// Android 8 input has no motionevent
+			rw.WriteString("Error: Unknown command: motionevent\nUsage: input [<source>] <command> [<arg>...]\n")
+		}
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	points := []image.Point{{10, 20}, {30, 40}}
+	assert.NoError(t, device.Gesture(points, time.Millisecond))
+	assert.Equal(t, "shell:input motionevent DOWN 10 20; sleep 0.001; input motionevent MOVE 30 40; sleep 0.001; input motionevent UP 30 40", <-cmds)
+
+	err := device.Gesture([]image.Point{{1, 2}, {3, 4}}, time.Millisecond)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "require Android 9+")
+	}
+}
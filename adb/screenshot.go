@@ -0,0 +1,190 @@
+package adb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// openService open transport and send service request, eg: exec:ls, framebuffer:
+// conn is closed when ctx is done
+func (d *Device) openService(ctx context.Context, service string) (conn *ADBConn, err error) {
+	conn, err = d.OpenTransportContext(ctx)
+	if err != nil {
+		return
+	}
+	conn.EncodeString(service)
+	if err = conn.CheckOKAY(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Screenshot returns image of the default display
+func (d *Device) Screenshot(ctx context.Context) (image.Image, error) {
+	return d.ScreenshotDisplay(ctx, "")
+}
+
+// ScreenshotDisplay returns image of display, display id can be found in dumpsys SurfaceFlinger --display-id
+// exec:screencap is used first, framebuffer: is used when exec: is not supported (Android < 5.0)
+func (d *Device) ScreenshotDisplay(ctx context.Context, display string) (img image.Image, err error) {
+	cmd := "exec:screencap -p"
+	if display != "" {
+		// exec: is run by sh -c in adbd, only numbers are accepted
+		id, err := strconv.ParseUint(display, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid display id: %q", display)
+		}
+		cmd += " -d " + strconv.FormatUint(id, 10)
+	}
+	img, err = d.screencap(ctx, cmd)
+	if err == nil || display != "" || ctx.Err() != nil {
+		return
+	}
+	return d.framebuffer(ctx)
+}
+
+func (d *Device) screencap(ctx context.Context, cmd string) (image.Image, error) {
+	conn, err := d.openService(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "screencap: %q", firstLine(data))
+	}
+	return img, nil
+}
+
+func firstLine(data []byte) string {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[:i]
+	}
+	if len(data) > 80 {
+		data = data[:80]
+	}
+	return string(data)
+}
+
+// FramebufferHeader is sent by framebuffer: service before pixel data
+// Ref: https://github.com/aosp-mirror/platform_system_core/blob/master/adb/daemon/framebuffer_service.cpp
+type FramebufferHeader struct {
+	Version     uint32
+	Bpp         uint32
+	ColorSpace  uint32 // only in version 2
+	Size        uint32
+	Width       uint32
+	Height      uint32
+	RedOffset   uint32
+	RedLength   uint32
+	BlueOffset  uint32
+	BlueLength  uint32
+	GreenOffset uint32
+	GreenLength uint32
+	AlphaOffset uint32
+	AlphaLength uint32
+}
+
+func readFramebufferHeader(r io.Reader) (h FramebufferHeader, err error) {
+	readUint32s := func(vs ...*uint32) {
+		for _, v := range vs {
+			if err != nil {
+				return
+			}
+			err = binary.Read(r, binary.LittleEndian, v)
+		}
+	}
+	readUint32s(&h.Version)
+	switch h.Version {
+	case 16: // deprecated RGB565 format
+		h.Bpp = 16
+		readUint32s(&h.Size, &h.Width, &h.Height)
+		h.RedOffset, h.RedLength = 11, 5
+		h.GreenOffset, h.GreenLength = 5, 6
+		h.BlueOffset, h.BlueLength = 0, 5
+	case 1:
+		readUint32s(&h.Bpp, &h.Size, &h.Width, &h.Height,
+			&h.RedOffset, &h.RedLength, &h.BlueOffset, &h.BlueLength,
+			&h.GreenOffset, &h.GreenLength, &h.AlphaOffset, &h.AlphaLength)
+	case 2:
+		readUint32s(&h.Bpp, &h.ColorSpace, &h.Size, &h.Width, &h.Height,
+			&h.RedOffset, &h.RedLength, &h.BlueOffset, &h.BlueLength,
+			&h.GreenOffset, &h.GreenLength, &h.AlphaOffset, &h.AlphaLength)
+	default:
+		if err == nil {
+			err = fmt.Errorf("unsupported framebuffer version: %d", h.Version)
+		}
+	}
+	return
+}
+
+// framebuffer read raw pixels from framebuffer: service
+func (d *Device) framebuffer(ctx context.Context) (image.Image, error) {
+	conn, err := d.openService(ctx, "framebuffer:")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return decodeFramebuffer(conn)
+}
+
+// maxFramebufferSide is the max width or height accepted from framebuffer header
+const maxFramebufferSide = 1 << 14
+
+func decodeFramebuffer(r io.Reader) (image.Image, error) {
+	h, err := readFramebufferHeader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "framebuffer header")
+	}
+	bytesPerPixel := int(h.Bpp / 8)
+	pixels := uint64(h.Width) * uint64(h.Height)
+	// size is sent by device, it should be enough for pixels and no more than 4 bytes per pixel
+	if bytesPerPixel < 2 || bytesPerPixel > 4 || h.Width > maxFramebufferSide || h.Height > maxFramebufferSide ||
+		uint64(h.Size) < pixels*uint64(bytesPerPixel) || uint64(h.Size) > pixels*4 {
+		return nil, fmt.Errorf("invalid framebuffer header: %+v", h)
+	}
+	data := make([]byte, h.Size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	channel := func(pixel, offset, length uint32) uint8 {
+		if length == 0 {
+			return 0xff
+		}
+		v := (pixel >> offset) & (1<<length - 1)
+		return uint8(v * 0xff / (1<<length - 1))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, int(h.Width), int(h.Height)))
+	for y := 0; y < int(h.Height); y++ {
+		for x := 0; x < int(h.Width); x++ {
+			i := (y*int(h.Width) + x) * bytesPerPixel
+			var pixel uint32
+			for b := bytesPerPixel - 1; b >= 0; b-- { // little endian
+				pixel = pixel<<8 | uint32(data[i+b])
+			}
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: channel(pixel, h.RedOffset, h.RedLength),
+				G: channel(pixel, h.GreenOffset, h.GreenLength),
+				B: channel(pixel, h.BlueOffset, h.BlueLength),
+				A: channel(pixel, h.AlphaOffset, h.AlphaLength),
+			})
+		}
+	}
+	return img, nil
+}
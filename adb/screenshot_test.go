@@ -0,0 +1,58 @@
+package adb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeFramebuffer(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	// version 1, RGBA_8888, 2x1
+	binary.Write(buf, binary.LittleEndian, []uint32{1, 32, 8, 2, 1, 0, 8, 16, 8, 8, 8, 24, 8})
+	buf.Write([]byte{0xff, 0x00, 0x00, 0xff, 0x00, 0x00, 0xff, 0x80})
+	img, err := decodeFramebuffer(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, color.NRGBA{0xff, 0, 0, 0xff}, img.At(0, 0))
+	assert.Equal(t, color.NRGBA{0, 0, 0xff, 0x80}, img.At(1, 0))
+
+	buf.Reset()
+	// version 16, RGB565, 1x1
+	binary.Write(buf, binary.LittleEndian, []uint32{16, 2, 1, 1})
+	binary.Write(buf, binary.LittleEndian, uint16(0x07e0)) // green
+	img, err = decodeFramebuffer(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, color.NRGBA{0, 0xff, 0, 0xff}, img.At(0, 0))
+}
+
+func TestDecodeFramebufferInvalidHeader(t *testing.T) {
+	for _, header := range [][]uint32{
+		{1, 32, 0xffffffff, 0x10000, 0x10000, 0, 8, 16, 8, 8, 8, 24, 8}, // width*height overflows uint32
+		{1, 32, 0xfffffff0, 100, 100, 0, 8, 16, 8, 8, 8, 24, 8},         // size larger than pixels
+		{1, 32, 4, 2, 1, 0, 8, 16, 8, 8, 8, 24, 8},                      // size smaller than pixels
+		{1, 8, 2, 2, 1, 0, 8, 16, 8, 8, 8, 24, 8},                       // unsupported bpp
+	} {
+		buf := bytes.NewBuffer(nil)
+		binary.Write(buf, binary.LittleEndian, header)
+		_, err := decodeFramebuffer(buf)
+		assert.Error(t, err, "%v", header)
+	}
+}
+
+func TestScreenshotDisplayInvalid(t *testing.T) {
+	device := NewClient("127.0.0.1:1").DeviceWithSerial("fake")
+	for _, display := range []string{"0; rm -rf /sdcard/*", "-1", "1 2", "$(id)"} {
+		_, err := device.ScreenshotDisplay(context.Background(), display)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid display id")
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatteryInfo is parsed from dumpsys battery
+type BatteryInfo struct {
+	Level       int     `json:"level"`
+	Scale       int     `json:"scale"`
+	Temperature float64 `json:"temperature"` // celsius
+	Status      int     `json:"status"`
+	Health      int     `json:"health"`
+	ACPowered   bool    `json:"acPowered"`
+	USBPowered  bool    `json:"usbPowered"`
+}
+
+// Percent returns battery level in range [0, 100]
+func (b BatteryInfo) Percent() int {
+	if b.Scale <= 0 || b.Scale == 100 {
+		return b.Level
+	}
+	return b.Level * 100 / b.Scale
+}
+
+var batteryFieldRE = regexp.MustCompile(`(?m)^\s*([\w ]+):\s*(\S+)\s*$`)
+
+func parseBattery(output string) (info BatteryInfo, err error) {
+	found := false
+	for _, m := range batteryFieldRE.FindAllStringSubmatch(output, -1) {
+		key, value := strings.TrimSpace(m[1]), m[2]
+		n, _ := strconv.Atoi(value)
+		switch key {
+		case "level":
+			info.Level = n
+			found = true
+		case "scale":
+			info.Scale = n
+		case "temperature":
+			// reported in tenths of a degree
+			info.Temperature = float64(n) / 10
+		case "status":
+			info.Status = n
+		case "health":
+			info.Health = n
+		case "AC powered":
+			info.ACPowered = value == "true"
+		case "USB powered":
+			info.USBPowered = value == "true"
+		}
+	}
+	if !found {
+		err = errors.New("battery level not found in dumpsys battery")
+	}
+	return
+}
+
+// Battery returns battery level and temperature
+func (d *Device) Battery() (info BatteryInfo, err error) {
+	return d.BatteryContext(context.Background())
+}
+
+func (d *Device) BatteryContext(ctx context.Context) (info BatteryInfo, err error) {
+	output, err := d.RunCommandContext(ctx, "dumpsys", "battery")
+	if err != nil {
+		return
+	}
+	return parseBattery(output)
+}
+
+// DiskUsage is parsed from df, sizes are in bytes
+type DiskUsage struct {
+	Path      string `json:"path"`
+	Total     int64  `json:"total"`
+	Used      int64  `json:"used"`
+	Available int64  `json:"available"`
+}
+
+// parseDfSize parse size like 1.5G, 512M or number of 1K blocks
+func parseDfSize(s string) (int64, error) {
+	units := map[byte]float64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}
+	if len(s) > 0 {
+		if unit, ok := units[s[len(s)-1]]; ok {
+			f, err := strconv.ParseFloat(s[:len(s)-1], 64)
+			return int64(f * unit), err
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n * 1024, err
+}
+
+// parseDf parse the last line of df output
+//
+// toybox: Filesystem 1K-blocks Used Available Use% Mounted on
+// old toolbox: Filesystem Size Used Free Blksize
+func parseDf(output string) (usage DiskUsage, err error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return usage, fmt.Errorf("unexpected df output: %q", output)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return usage, fmt.Errorf("unexpected df output: %q", output)
+	}
+	sizes := make([]int64, 3)
+	for i := range sizes {
+		if sizes[i], err = parseDfSize(fields[i+1]); err != nil {
+			return usage, fmt.Errorf("unexpected df output: %q", output)
+		}
+	}
+	usage.Path = fields[0]
+	if len(fields) >= 6 {
+		usage.Path = fields[5]
+	}
+	usage.Total, usage.Used, usage.Available = sizes[0], sizes[1], sizes[2]
+	return usage, nil
+}
+
+// DiskUsage returns disk usage of the filesystem which contains path
+func (d *Device) DiskUsage(path string) (usage DiskUsage, err error) {
+	return d.DiskUsageContext(context.Background(), path)
+}
+
+func (d *Device) DiskUsageContext(ctx context.Context, path string) (usage DiskUsage, err error) {
+	output, err := d.RunCommandContext(ctx, "df", path)
+	if err != nil {
+		return
+	}
+	return parseDf(output)
+}
+
+func parseUptime(output string) (time.Duration, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected uptime: %q", output)
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected uptime: %q", output)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Uptime returns time since device boot
+func (d *Device) Uptime() (time.Duration, error) {
+	return d.UptimeContext(context.Background())
+}
+
+func (d *Device) UptimeContext(ctx context.Context) (time.Duration, error) {
+	output, err := d.RunCommandContext(ctx, "cat", "/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+	return parseUptime(output)
+}
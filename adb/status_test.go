@@ -0,0 +1,86 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBattery(t *testing.T) {
+	output := `Current Battery Service state:
+  AC powered: false
+  USB powered: true
+  Wireless powered: false
+  Max charging current: 500000
+  status: 2
+  health: 2
+  present: true
+  level: 87
+  scale: 100
+  voltage: 4192
+  temperature: 312
+  technology: Li-ion
+`
+	info, err := parseBattery(output)
+	assert.NoError(t, err)
+	assert.Equal(t, 87, info.Percent())
+	assert.Equal(t, 31.2, info.Temperature)
+	assert.True(t, info.USBPowered)
+	assert.False(t, info.ACPowered)
+
+	_, err = parseBattery("Can't find service: battery")
+	assert.Error(t, err)
+}
+
+func TestParseDf(t *testing.T) {
+	usage, err := parseDf(`Filesystem      1K-blocks    Used Available Use% Mounted on
+/dev/block/dm-0  24608768 8215560  16261752  34% /data
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "/data", usage.Path)
+	assert.Equal(t, int64(16261752*1024), usage.Available)
+
+	usage, err = parseDf(`Filesystem               Size     Used     Free   Blksize
+/data                    5.9G     1.2G     4.7G   4096
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "/data", usage.Path)
+	assert.InDelta(t, 4.7*(1<<30), float64(usage.Available), 1)
+
+	_, err = parseDf("/data: Permission denied")
+	assert.Error(t, err)
+}
+
+func TestParseUptime(t *testing.T) {
+	d, err := parseUptime("3625.51 12010.33\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 3625510*time.Millisecond, d)
+}
+
+func TestUptimeContext(t *testing.T) {
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		if service == "shell:cat /proc/uptime" {
+			rw.WriteString("3723.50 7000.12\n")
+			rw.Flush()
+			return
+		}
+		rw.Flush()
+		io.Copy(ioutil.Discard, rw) // hang until connection closed
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	uptime, err := device.UptimeContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour+2*time.Minute+3500*time.Millisecond, uptime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = device.BatteryContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
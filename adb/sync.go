@@ -0,0 +1,172 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// syncReader read DATA chunks of sync RECV response
+// Ref: https://github.com/aosp-mirror/platform_system_core/blob/master/adb/SYNC.TXT
+type syncReader struct {
+	conn   *ADBConn
+	remain uint32
+	done   bool
+}
+
+func (r *syncReader) Read(p []byte) (n int, err error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	if r.remain == 0 {
+		id, err := r.conn.ReadNString(4)
+		if err != nil {
+			return 0, err
+		}
+		length, err := r.conn.ReadUint32()
+		if err != nil {
+			return 0, err
+		}
+		switch id {
+		case "DATA":
+			r.remain = length
+		case "DONE":
+			r.done = true
+			return 0, io.EOF
+		case _FAIL:
+			msg, err := r.conn.ReadNString(int(length))
+			if err != nil {
+				return 0, err
+			}
+			return 0, errors.New(msg)
+		default:
+			return 0, fmt.Errorf("Invalid sync response: %q", id)
+		}
+	}
+	if uint32(len(p)) > r.remain {
+		p = p[:r.remain]
+	}
+	n, err = r.conn.Read(p)
+	r.remain -= uint32(n)
+	return
+}
+
+func (r *syncReader) Close() error {
+	return r.conn.Close()
+}
+
+// OpenRead open remote file through sync protocol, reader should be Close after using
+func (d *Device) OpenRead(path string) (rc io.ReadCloser, err error) {
+	return d.OpenReadContext(context.Background(), path)
+}
+
+// OpenReadContext is the same as OpenRead, reader is closed when ctx is done
+func (d *Device) OpenReadContext(ctx context.Context, path string) (rc io.ReadCloser, err error) {
+	conn, err := d.OpenTransportContext(ctx)
+	if err != nil {
+		return
+	}
+	conn.EncodeString("sync:")
+	if err = conn.CheckOKAY(); err != nil {
+		conn.Close()
+		return
+	}
+	if err = conn.WriteObjects("RECV", uint32(len(path)), path); err != nil {
+		conn.Close()
+		return
+	}
+	return &syncReader{conn: conn}, nil
+}
+
+// Pull copy remote file to local
+func (d *Device) Pull(remotePath, localPath string) (err error) {
+	return d.PullContext(context.Background(), remotePath, localPath)
+}
+
+func (d *Device) PullContext(ctx context.Context, remotePath, localPath string) (err error) {
+	rc, err := d.OpenReadContext(ctx, remotePath)
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	f, err := os.Create(localPath)
+	if err != nil {
+		return
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(localPath)
+		}
+	}()
+	_, err = io.Copy(f, rc)
+	return
+}
+
+// syncDataMax is the max size of DATA chunk
+const syncDataMax = 64 * 1024
+
+// Push copy r to remote path through sync protocol, mode is the permission of remote file
+func (d *Device) Push(r io.Reader, remotePath string, mode os.FileMode) error {
+	return d.PushContext(context.Background(), r, remotePath, mode)
+}
+
+func (d *Device) PushContext(ctx context.Context, r io.Reader, remotePath string, mode os.FileMode) (err error) {
+	conn, err := d.OpenTransportContext(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.EncodeString("sync:")
+	if err = conn.CheckOKAY(); err != nil {
+		return
+	}
+	target := fmt.Sprintf("%s,%d", remotePath, uint32(mode.Perm()))
+	if err = conn.WriteObjects("SEND", uint32(len(target)), target); err != nil {
+		return
+	}
+	buf := make([]byte, syncDataMax)
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			if err = conn.WriteObjects("DATA", uint32(n)); err != nil {
+				return
+			}
+			if _, err = conn.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+	if err = conn.WriteObjects("DONE", uint32(time.Now().Unix())); err != nil {
+		return
+	}
+	id, err := conn.ReadNString(4)
+	if err != nil {
+		return
+	}
+	length, err := conn.ReadUint32()
+	if err != nil {
+		return
+	}
+	switch id {
+	case _OKAY:
+		return nil
+	case _FAIL:
+		msg, err := conn.ReadNString(int(length))
+		if err != nil {
+			return err
+		}
+		return errors.New(msg)
+	default:
+		return fmt.Errorf("Invalid sync response: %q", id)
+	}
+}
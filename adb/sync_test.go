@@ -0,0 +1,88 @@
+package adb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serveSyncSend read SEND request and returns target and content
+func serveSyncSend(rw *bufio.ReadWriter) (target string, data []byte) {
+	readObject := func() (string, []byte) {
+		id := make([]byte, 4)
+		io.ReadFull(rw, id)
+		var length uint32
+		binary.Read(rw, binary.LittleEndian, &length)
+		if string(id) == "DONE" {
+			return "DONE", nil
+		}
+		body := make([]byte, length)
+		io.ReadFull(rw, body)
+		return string(id), body
+	}
+	_, body := readObject() // SEND
+	target = string(body)
+	for {
+		id, chunk := readObject()
+		if id != "DATA" {
+			break
+		}
+		data = append(data, chunk...)
+	}
+	rw.WriteString("OKAY\x00\x00\x00\x00")
+	rw.Flush()
+	return
+}
+
+func TestDeviceInstall(t *testing.T) {
+	pushed := make(chan string, 1)
+	commands := make(chan string, 10)
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		switch {
+		case service == "sync:":
+			rw.Flush()
+			target, data := serveSyncSend(rw)
+			pushed <- target + " " + string(data)
+		case strings.HasPrefix(service, "shell:pm install"):
+			commands <- service
+			if strings.Contains(service, "-d") {
+				rw.WriteString("Success\n")
+			} else {
+				rw.WriteString("Failure [INSTALL_FAILED_VERSION_DOWNGRADE: Downgrade detected]\n")
+			}
+			rw.Flush()
+		default:
+			rw.Flush()
+		}
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	tmpDir, err := ioutil.TempDir("", "fa-adb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	apkPath := filepath.Join(tmpDir, "app.apk")
+	ioutil.WriteFile(apkPath, []byte(strings.Repeat("x", 100*1024)), 0644)
+
+	assert.NoError(t, device.Install(apkPath, "-r", "-d"))
+	target := <-pushed
+	assert.True(t, strings.HasPrefix(target, "/data/local/tmp/fa-install-"))
+	assert.True(t, strings.HasSuffix(target, ".apk,420 "+strings.Repeat("x", 100*1024)))
+
+	err = device.Install(apkPath, "-r")
+	<-pushed
+	if ierr, ok := err.(*InstallError); assert.True(t, ok) {
+		assert.Equal(t, "INSTALL_FAILED_VERSION_DOWNGRADE", ierr.Code)
+	}
+	assert.Len(t, commands, 2)
+	assert.Contains(t, <-commands, "shell:pm install -r -d /data/local/tmp/fa-install-")
+}
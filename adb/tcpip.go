@@ -0,0 +1,116 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TCPIP restart adbd on device listening on TCP port
+func (d *Device) TCPIP(port int) error {
+	return d.TCPIPContext(context.Background(), port)
+}
+
+func (d *Device) TCPIPContext(ctx context.Context, port int) error {
+	conn, err := d.OpenTransportContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.EncodeString(fmt.Sprintf("tcpip:%d", port))
+	if err := conn.CheckOKAY(); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+	if output := strings.TrimSpace(string(data)); !strings.Contains(output, "restarting") {
+		return errors.New("tcpip: " + output)
+	}
+	return nil
+}
+
+// Connect connect to device over TCP/IP, addr is host:port
+func (c *Client) Connect(addr string) error {
+	return c.ConnectContext(context.Background(), addr)
+}
+
+func (c *Client) ConnectContext(ctx context.Context, addr string) error {
+	resp, err := c.roundTripSingleResponse(ctx, "host:connect:"+addr)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(resp, "connected to") || strings.Contains(resp, "cannot") || strings.Contains(resp, "failed") {
+		return errors.New(resp)
+	}
+	return nil
+}
+
+// Disconnect disconnect from TCP/IP device
+func (c *Client) Disconnect(addr string) error {
+	return c.DisconnectContext(context.Background(), addr)
+}
+
+func (c *Client) DisconnectContext(ctx context.Context, addr string) error {
+	resp, err := c.roundTripSingleResponse(ctx, "host:disconnect:"+addr)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(resp, "error") || strings.Contains(resp, "no such device") {
+		return errors.New(resp)
+	}
+	return nil
+}
+
+// Pair pair with Android 11+ device using the code shown in wireless debugging
+func (c *Client) Pair(addr, code string) error {
+	return c.PairContext(context.Background(), addr, code)
+}
+
+func (c *Client) PairContext(ctx context.Context, addr, code string) error {
+	resp, err := c.roundTripSingleResponse(ctx, "host:pair:"+code+":"+addr)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(resp, "Successfully paired") {
+		return errors.New(resp)
+	}
+	return nil
+}
+
+// WaitForState wait until device with serial is in state through host:track-devices
+func (c *Client) WaitForState(serial string, state DeviceState, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := c.WaitForStateContext(ctx, serial, state)
+	if err == context.DeadlineExceeded {
+		return fmt.Errorf("wait for %s to be %s timeout", serial, state)
+	}
+	return err
+}
+
+// WaitForStateContext wait until device with serial is in state or ctx is done
+func (c *Client) WaitForStateContext(ctx context.Context, serial string, state DeviceState) error {
+	conn, err := c.roundTrip(ctx, "host:track-devices")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.CheckOKAY(); err != nil {
+		return err
+	}
+	for {
+		lines, err := conn.DecodeString()
+		if err != nil {
+			return err
+		}
+		if parseDeviceStates(lines)[serial] == state {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	watchMinBackoff = 500 * time.Millisecond
+	watchMaxBackoff = 10 * time.Second
+)
+
+// DeviceEvent is emitted when device state changed.
+// Plugged in: StateDisconnected->StateOffline->StateOnline
+// Unplugged:  StateOnline->StateDisconnected
+type DeviceEvent struct {
+	Serial   string
+	OldState DeviceState
+	NewState DeviceState
+}
+
+func (e DeviceEvent) String() string {
+	return fmt.Sprintf("%s: %s->%s", e.Serial, e.OldState, e.NewState)
+}
+
+// CameOnline returns true if this event represents a device coming online.
+func (e DeviceEvent) CameOnline() bool {
+	return e.OldState != StateOnline && e.NewState == StateOnline
+}
+
+// WentOffline returns true if this event represents a device going offline.
+func (e DeviceEvent) WentOffline() bool {
+	return e.OldState == StateOnline && e.NewState != StateOnline
+}
+
+// parseDeviceStates parse lines of "<serial>\t<state>"
+func parseDeviceStates(lines string) map[string]DeviceState {
+	states := make(map[string]DeviceState)
+	for _, line := range strings.Split(lines, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		states[parts[0]] = DeviceState(parts[1])
+	}
+	return states
+}
+
+// diffDeviceStates returns events sorted by serial, devices not in current are disconnected
+func diffDeviceStates(last, current map[string]DeviceState) []DeviceEvent {
+	events := make([]DeviceEvent, 0)
+	for serial, state := range current {
+		old, ok := last[serial]
+		if !ok {
+			old = StateDisconnected
+		}
+		if old != state {
+			events = append(events, DeviceEvent{Serial: serial, OldState: old, NewState: state})
+		}
+	}
+	for serial, old := range last {
+		if _, ok := current[serial]; !ok && old != StateDisconnected {
+			events = append(events, DeviceEvent{Serial: serial, OldState: old, NewState: StateDisconnected})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Serial < events[j].Serial
+	})
+	return events
+}
+
+// trackDevices call fn with every snapshot of host:track-devices until error or ctx done
+func (c *Client) trackDevices(ctx context.Context, fn func(states map[string]DeviceState)) error {
+	conn, err := c.roundTrip(ctx, "host:track-devices")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.CheckOKAY(); err != nil {
+		return err
+	}
+	for {
+		lines, err := conn.DecodeString()
+		if err != nil {
+			return err
+		}
+		fn(parseDeviceStates(lines))
+	}
+}
+
+// Watch emit device events until ctx is done.
+// Every snapshot of track-devices is compared with the last one, so unplugged devices are reported as disconnected.
+// Connection is retried with backoff when adb server is restarted or killed, adb server is started if not running.
+// If reconnecting fails, devices of the last snapshot are reported as disconnected.
+func (c *Client) Watch(ctx context.Context) <-chan DeviceEvent {
+	C := make(chan DeviceEvent)
+	go func() {
+		defer close(C)
+		states := make(map[string]DeviceState)
+		// emit returns false if ctx is done
+		emit := func(events []DeviceEvent) bool {
+			for _, ev := range events {
+				select {
+				case C <- ev:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		backoff := watchMinBackoff
+		for {
+			received := false
+			c.trackDevices(ctx, func(current map[string]DeviceState) {
+				received = true
+				backoff = watchMinBackoff
+				if emit(diffDeviceStates(states, current)) {
+					states = current
+				}
+			})
+			if !received && len(states) > 0 && ctx.Err() == nil {
+				// adb server is still down, devices can not be used until it is back
+				if emit(diffDeviceStates(states, nil)) {
+					states = make(map[string]DeviceState)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > watchMaxBackoff {
+				backoff = watchMaxBackoff
+			}
+		}
+	}()
+	return C
+}
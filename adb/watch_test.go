@@ -0,0 +1,108 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDeviceStates(t *testing.T) {
+	states := parseDeviceStates("0123456789ABCDEF\tdevice\n192.168.1.23:5555\toffline\nemulator-5554\tunauthorized\n")
+	assert.Len(t, states, 3)
+	assert.Equal(t, StateOnline, states["0123456789ABCDEF"])
+	assert.Equal(t, StateOffline, states["192.168.1.23:5555"])
+	assert.Equal(t, StateUnauthorized, states["emulator-5554"])
+	assert.Len(t, parseDeviceStates(""), 0)
+}
+
+func TestDiffDeviceStates(t *testing.T) {
+	events := diffDeviceStates(map[string]DeviceState{
+		"a": StateOnline,
+		"b": StateOnline,
+		"c": StateOffline,
+	}, map[string]DeviceState{
+		"a": StateOnline,
+		"c": StateOnline,
+		"d": StateUnauthorized,
+	})
+	assert.Equal(t, []DeviceEvent{
+		{Serial: "b", OldState: StateOnline, NewState: StateDisconnected},
+		{Serial: "c", OldState: StateOffline, NewState: StateOnline},
+		{Serial: "d", OldState: StateDisconnected, NewState: StateUnauthorized},
+	}, events)
+	assert.True(t, events[0].WentOffline())
+	assert.True(t, events[1].CameOnline())
+}
+
+func TestWatchReconnect(t *testing.T) {
+	// the first connection is closed like adb server restarted, the last one is kept open
+	snapshots := make(chan []string, 2)
+	snapshots <- []string{"a\tdevice\nb\toffline\n", "a\tdevice\nb\tdevice\n"}
+	snapshots <- []string{"b\tdevice\n"}
+	close(snapshots)
+	addr, closeFn := fakeServer(t, func(req string, rw *bufio.ReadWriter) {
+		messages, ok := <-snapshots
+		if !ok {
+			return
+		}
+		rw.WriteString("OKAY")
+		for _, msg := range messages {
+			fmt.Fprintf(rw, "%04x%s", len(msg), msg)
+		}
+		if len(snapshots) == 0 {
+			rw.Flush()
+			io.Copy(ioutil.Discard, rw)
+		}
+	})
+	defer closeFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var events []string
+	for ev := range NewClient(addr).Watch(ctx) {
+		events = append(events, ev.String())
+		if len(events) == 4 {
+			cancel()
+		}
+	}
+	assert.Equal(t, []string{
+		"a: disconnected->device",
+		"b: disconnected->offline",
+		"b: offline->device",
+		"a: device->disconnected",
+	}, events)
+}
+
+func TestWatchServerDown(t *testing.T) {
+	addr, closeFn := fakeServer(t, func(req string, rw *bufio.ReadWriter) {
+		msg := "a\tdevice\nb\tunauthorized\n"
+		fmt.Fprintf(rw, "OKAY%04x%s", len(msg), msg)
+	})
+	client := NewClientWithOptions(ClientOptions{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var events []string
+	for ev := range client.Watch(ctx) {
+		events = append(events, ev.String())
+		if len(events) == 2 {
+			// adb server is killed and not restarted
+			closeFn()
+		}
+		if len(events) == 4 {
+			cancel()
+		}
+	}
+	assert.Equal(t, []string{
+		"a: disconnected->device",
+		"b: disconnected->unauthorized",
+		"a: device->disconnected",
+		"b: unauthorized->disconnected",
+	}, events)
+}
@@ -0,0 +1,183 @@
+package adb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WifiInfo is parsed from ip addr, cmd wifi status and dumpsys wifi
+type WifiInfo struct {
+	Enabled   bool   `json:"enabled"`
+	Connected bool   `json:"connected"`
+	SSID      string `json:"ssid"`
+	BSSID     string `json:"bssid"`
+	RSSI      int    `json:"rssi"`      // dBm
+	LinkSpeed int    `json:"linkSpeed"` // Mbps
+	Frequency int    `json:"frequency"` // MHz
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`
+}
+
+var (
+	wifiEnabledRE   = regexp.MustCompile(`(?i)wi-?fi is (enabled|disabled)`)
+	wifiInfoLineRE  = regexp.MustCompile(`(?m)(?:mWifiInfo|WifiInfo:)\s+(SSID: .*)$`)
+	wifiSSIDRE      = regexp.MustCompile(`^SSID: (?:"([^"]*)"|([^,]*)),`)
+	wifiBSSIDRE     = regexp.MustCompile(`BSSID: ([0-9a-fA-F:]{17})`)
+	wifiRSSIRE      = regexp.MustCompile(`RSSI: (-?\d+)`)
+	wifiLinkSpeedRE = regexp.MustCompile(`Link speed: (-?\d+)\s?Mbps`)
+	wifiFrequencyRE = regexp.MustCompile(`Frequency: (\d+)\s?MHz`)
+	inetRE          = regexp.MustCompile(`inet (\d+\.\d+\.\d+\.\d+)/`)
+	linkEtherRE     = regexp.MustCompile(`link/ether ([0-9a-fA-F:]{17})`)
+)
+
+// parseWifiStatus parse output of cmd wifi status or dumpsys wifi
+func parseWifiStatus(output string, info *WifiInfo) error {
+	m := wifiEnabledRE.FindStringSubmatch(output)
+	if m == nil {
+		return errors.New("wifi state not found")
+	}
+	info.Enabled = m[1] == "enabled"
+
+	m = wifiInfoLineRE.FindStringSubmatch(output)
+	if m == nil {
+		return nil
+	}
+	line := m[1]
+	if m := wifiSSIDRE.FindStringSubmatch(line); m != nil {
+		info.SSID = m[1] + m[2]
+	}
+	if info.SSID == "<unknown ssid>" {
+		info.SSID = ""
+	}
+	if m := wifiBSSIDRE.FindStringSubmatch(line); m != nil && m[1] != "00:00:00:00:00:00" {
+		info.BSSID = m[1]
+	}
+	if m := wifiRSSIRE.FindStringSubmatch(line); m != nil {
+		info.RSSI, _ = strconv.Atoi(m[1])
+	}
+	if m := wifiLinkSpeedRE.FindStringSubmatch(line); m != nil {
+		info.LinkSpeed, _ = strconv.Atoi(m[1])
+	}
+	if m := wifiFrequencyRE.FindStringSubmatch(line); m != nil {
+		info.Frequency, _ = strconv.Atoi(m[1])
+	}
+	info.Connected = info.Enabled && info.SSID != "" && strings.Contains(line, "Supplicant state: COMPLETED")
+	return nil
+}
+
+// parseIPAddr parse output of ip addr show <iface>
+func parseIPAddr(output string, info *WifiInfo) {
+	if m := inetRE.FindStringSubmatch(output); m != nil {
+		info.IP = m[1]
+	}
+	if m := linkEtherRE.FindStringSubmatch(output); m != nil {
+		info.MAC = m[1]
+	}
+}
+
+// WifiInfo returns wifi state, cmd wifi status is used when available (Android 11+)
+func (d *Device) WifiInfo() (info WifiInfo, err error) {
+	return d.WifiInfoContext(context.Background())
+}
+
+func (d *Device) WifiInfoContext(ctx context.Context) (info WifiInfo, err error) {
+	output, err := d.RunCommandContext(ctx, "cmd", "wifi", "status")
+	if err != nil || parseWifiStatus(output, &info) != nil {
+		info = WifiInfo{}
+		output, err = d.RunCommandContext(ctx, "dumpsys", "wifi")
+		if err != nil {
+			return
+		}
+		if err = parseWifiStatus(output, &info); err != nil {
+			return
+		}
+	}
+	output, err = d.RunCommandContext(ctx, "ip", "addr", "show", "wlan0")
+	if err != nil {
+		return
+	}
+	parseIPAddr(output, &info)
+	return info, nil
+}
+
+// SetWifiEnabled turn wifi on or off
+func (d *Device) SetWifiEnabled(enabled bool) error {
+	return d.SetWifiEnabledContext(context.Background(), enabled)
+}
+
+func (d *Device) SetWifiEnabledContext(ctx context.Context, enabled bool) error {
+	state := "disable"
+	if enabled {
+		state = "enable"
+	}
+	output, err := d.RunCommandContext(ctx, "svc", "wifi", state)
+	if err != nil {
+		return err
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return errors.New(output)
+	}
+	return nil
+}
+
+// WifiConnect connect to network through cmd wifi connect-network.
+// security is one of open, owe, wpa2, wpa3, it is set to wpa2 or open by password if empty.
+func (d *Device) WifiConnect(ssid, password, security string) error {
+	return d.WifiConnectContext(context.Background(), ssid, password, security)
+}
+
+func (d *Device) WifiConnectContext(ctx context.Context, ssid, password, security string) error {
+	if security == "" {
+		security = "open"
+		if password != "" {
+			security = "wpa2"
+		}
+	}
+	args := []string{"cmd", "wifi", "connect-network", ssid, security}
+	if password != "" {
+		args = append(args, password)
+	}
+	output, code, err := d.runCommandStatus(ctx, args...)
+	if err != nil {
+		return err
+	}
+	output = strings.TrimSpace(output)
+	if strings.Contains(output, "Unknown command") {
+		return errors.New("cmd wifi connect-network is not supported, require Android 11+")
+	}
+	if code != 0 {
+		return fmt.Errorf("connect %s: %s", ssid, output)
+	}
+	return nil
+}
+
+// WaitWifiConnected wait until wifi connected to ssid and got ip address
+func (d *Device) WaitWifiConnected(ssid string, timeout time.Duration) (info WifiInfo, err error) {
+	return d.WaitWifiConnectedContext(context.Background(), ssid, timeout)
+}
+
+func (d *Device) WaitWifiConnectedContext(ctx context.Context, ssid string, timeout time.Duration) (info WifiInfo, err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err = d.WifiInfoContext(ctx)
+		if err == nil && info.Connected && info.SSID == ssid && info.IP != "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = fmt.Errorf("wifi not connected to %s in %v", ssid, timeout)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return info, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
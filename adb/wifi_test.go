@@ -0,0 +1,81 @@
+package adb
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWifiStatus(t *testing.T) {
+	var info WifiInfo
+	err := parseWifiStatus(`Wifi is enabled
+Wifi scanning is always available
+==== Primary ClientModeManager instance ====
+Wifi is connected to "AndroidWifi"
+WifiInfo: SSID: "AndroidWifi", BSSID: 02:15:b2:00:01:00, MAC: 02:00:00:00:00:00, Supplicant state: COMPLETED, Wi-Fi standard: 4, RSSI: -50, Link speed: 72Mbps, Tx Link speed: 72Mbps, Max Supported Tx Link speed: 72Mbps, Rx Link speed: -1Mbps, Frequency: 2447MHz, Net ID: 0
+`, &info)
+	assert.NoError(t, err)
+	assert.True(t, info.Enabled)
+	assert.True(t, info.Connected)
+	assert.Equal(t, "AndroidWifi", info.SSID)
+	assert.Equal(t, "02:15:b2:00:01:00", info.BSSID)
+	assert.Equal(t, -50, info.RSSI)
+	assert.Equal(t, 72, info.LinkSpeed)
+	assert.Equal(t, 2447, info.Frequency)
+
+	info = WifiInfo{}
+	err = parseWifiStatus(`Wi-Fi is enabled
+Stay-awake conditions: 0
+mWifiInfo SSID: <unknown ssid>, BSSID: <none>, MAC: 02:00:00:00:00:00, Supplicant state: DISCONNECTED, RSSI: -127, Link speed: -1Mbps, Frequency: -1MHz, Net ID: -1
+`, &info)
+	assert.NoError(t, err)
+	assert.True(t, info.Enabled)
+	assert.False(t, info.Connected)
+	assert.Equal(t, "", info.SSID)
+
+	info = WifiInfo{}
+	assert.NoError(t, parseWifiStatus("Wifi is disabled\n", &info))
+	assert.False(t, info.Enabled)
+
+	assert.Error(t, parseWifiStatus("Unknown command: status", &info))
+}
+
+func TestParseIPAddr(t *testing.T) {
+	var info WifiInfo
+	parseIPAddr(`30: wlan0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc mq state UP group default qlen 3000
+    link/ether 3c:28:6d:12:34:56 brd ff:ff:ff:ff:ff:ff
+    inet 192.168.1.23/24 brd 192.168.1.255 scope global wlan0
+       valid_lft forever preferred_lft forever
+    inet6 fe80::3e28:6dff:fe12:3456/64 scope link
+`, &info)
+	assert.Equal(t, "192.168.1.23", info.IP)
+	assert.Equal(t, "3c:28:6d:12:34:56", info.MAC)
+}
+
+func TestWifiConnect(t *testing.T) {
+	addr, closeFn := fakeTransportServer(t, func(service string, rw *bufio.ReadWriter) {
+		switch {
+		case strings.Contains(service, "'fail safe'"): // ssid contains fail
+			rw.WriteString("Connection initiated\nfa-exit-code:0\n")
+		case strings.Contains(service, "badssid"):
+			rw.WriteString("Invalid argument: password too short\nfa-exit-code:255\n")
+		case strings.Contains(service, "android10"):
+			rw.WriteString("Unknown command: connect-network\nfa-exit-code:255\n")
+		}
+		rw.Flush()
+	})
+	defer closeFn()
+	device := NewClient(addr).DeviceWithSerial("fake")
+
+	assert.NoError(t, device.WifiConnect("fail safe", "12345678", ""))
+	err := device.WifiConnect("badssid", "1", "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "password too short")
+	}
+	err = device.WifiConnect("android10", "12345678", "")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not supported")
+	}
+}
@@ -9,16 +9,16 @@ import (
 )
 
 func TestAdbVersion(t *testing.T) {
-	version, err := DefaultAdbClient.Version()
+	version, err := newClient().ServerVersion()
 	if err != nil {
 		panic(err)
 	}
-	t.Logf("version: %s", version)
+	t.Logf("version: %d", version)
 }
 
 func TestAdbShell(t *testing.T) {
 	t.Log("Shell Test")
-	d := DefaultAdbClient.DeviceWithSerial("0123456789ABCDEF")
+	d := newDevice("0123456789ABCDEF")
 	rd, err := d.OpenShell("pwd")
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// launchApp start launcher activity of package and wait until it is in the foreground.
+// fallbackActivity is used when device can not resolve the activity (Android < 7.0)
+func launchApp(device *adb.Device, packageName, fallbackActivity string, wait bool, w io.Writer) (*adb.LaunchTiming, error) {
+	component, err := device.ResolveLaunchActivity(packageName)
+	if err != nil {
+		if fallbackActivity == "" {
+			return nil, errors.Wrap(err, "resolve launch activity")
+		}
+		if debug {
+			fmt.Fprintln(w, "resolve launch activity failed:", err)
+		}
+		if !strings.Contains(fallbackActivity, ".") {
+			fallbackActivity = "." + fallbackActivity
+		}
+		component = packageName + "/" + fallbackActivity
+	}
+	fmt.Fprintln(w, "Launch", component, "...")
+	timing, err := device.StartActivity(component, wait)
+	if err != nil {
+		return nil, err
+	}
+	if timing != nil {
+		fmt.Fprintf(w, "Status: %s\nThisTime: %v\nTotalTime: %v\nWaitTime: %v\n",
+			timing.Status, timing.ThisTime, timing.TotalTime, timing.WaitTime)
+	}
+	// launcher activity may live in another package, eg: an alias provided by a shared launcher module
+	foreground := packageName
+	if i := strings.Index(component, "/"); i > 0 {
+		foreground = component[:i]
+	}
+	return timing, device.WaitForeground(foreground, 10*time.Second)
+}
+
+type appResult struct {
+	Serial  string            `json:"serial"`
+	Package string            `json:"package"`
+	Action  string            `json:"action"`
+	Success bool              `json:"success"`
+	Error   string            `json:"error,omitempty"`
+	Timing  *adb.LaunchTiming `json:"timing,omitempty"`
+}
+
+// appAction run fn with the selected device and the first argument as package name
+func appAction(ctx *cli.Context, action string, fn func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error)) error {
+	if !ctx.Args().Present() {
+		return errors.New("package name should provided")
+	}
+	serial, err := chooseOne()
+	if err != nil {
+		return err
+	}
+	packageName := ctx.Args().First()
+	if !ctx.Bool("json") {
+		_, err = fn(newDevice(serial), packageName, os.Stdout)
+		if err == nil {
+			fmt.Println("Success")
+		}
+		return err
+	}
+	timing, err := fn(newDevice(serial), packageName, ioutil.Discard)
+	result := appResult{
+		Serial:  serial,
+		Package: packageName,
+		Action:  action,
+		Success: err == nil,
+		Timing:  timing,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	printJSON(result)
+	return err
+}
+
+func actAppList(ctx *cli.Context) error {
+	args := []string{}
+	if ctx.Bool("s") {
+		args = append(args, "-s")
+	}
+	if ctx.Bool("f") {
+		args = append(args, "-f")
+	}
+	if ctx.Bool("3") {
+		args = append(args, "-3")
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	packages, err := device.ListPackages(args...)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		printJSON(packages)
+		return nil
+	}
+	for _, name := range packages {
+		fmt.Println("package:" + name)
+	}
+	return nil
+}
+
+func actAppInfo(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return errors.New("package name should provided")
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	info, err := device.AppInfo(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		printJSON(info)
+		return nil
+	}
+	fmt.Println("Package:", info.PackageName)
+	fmt.Printf("Version: %s (%d)\n", info.VersionName, info.VersionCode)
+	fmt.Printf("SDK: min %d, target %d\n", info.MinSdk, info.TargetSdk)
+	fmt.Println("Installer:", info.Installer)
+	fmt.Println("CodePath:", info.CodePath)
+	fmt.Println("DataDir:", info.DataDir)
+	fmt.Println("FirstInstallTime:", info.FirstInstallTime)
+	fmt.Println("LastUpdateTime:", info.LastUpdateTime)
+	fmt.Println("Granted Permissions:")
+	for _, perm := range info.Permissions {
+		fmt.Println("  " + perm)
+	}
+	return nil
+}
+
+func actAppStart(ctx *cli.Context) error {
+	return appAction(ctx, "start", func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error) {
+		return launchApp(device, packageName, "", ctx.Bool("wait"), w)
+	})
+}
+
+func actAppStop(ctx *cli.Context) error {
+	return appAction(ctx, "stop", func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error) {
+		return nil, device.AppStop(packageName)
+	})
+}
+
+func actAppClear(ctx *cli.Context) error {
+	return appAction(ctx, "clear", func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error) {
+		return nil, device.AppClear(packageName)
+	})
+}
+
+func actAppUninstall(ctx *cli.Context) error {
+	return appAction(ctx, "uninstall", func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error) {
+		return nil, device.AppUninstall(packageName, ctx.Bool("keep-data"))
+	})
+}
+
+// permissionArgs returns permissions from args after package name,
+// android.permission. prefix is added when no dot in permission
+func permissionArgs(ctx *cli.Context) ([]string, error) {
+	if len(ctx.Args()) < 2 {
+		return nil, errors.New("package name and permission should provided")
+	}
+	perms := make([]string, 0, len(ctx.Args())-1)
+	for _, perm := range ctx.Args()[1:] {
+		if !strings.Contains(perm, ".") {
+			perm = "android.permission." + perm
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+func actAppGrant(ctx *cli.Context) error {
+	perms, err := permissionArgs(ctx)
+	if err != nil {
+		return err
+	}
+	return appAction(ctx, "grant", func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error) {
+		for _, perm := range perms {
+			if err := device.GrantPermission(packageName, perm); err != nil {
+				return nil, err
+			}
+			fmt.Fprintln(w, "Granted", perm)
+		}
+		return nil, nil
+	})
+}
+
+func actAppRevoke(ctx *cli.Context) error {
+	perms, err := permissionArgs(ctx)
+	if err != nil {
+		return err
+	}
+	return appAction(ctx, "revoke", func(device *adb.Device, packageName string, w io.Writer) (*adb.LaunchTiming, error) {
+		for _, perm := range perms {
+			if err := device.RevokePermission(packageName, perm); err != nil {
+				return nil, err
+			}
+			fmt.Fprintln(w, "Revoked", perm)
+		}
+		return nil, nil
+	})
+}
+
+func actCurrent(ctx *cli.Context) error {
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	printApp := func(app *adb.ForegroundApp) {
+		if ctx.Bool("json") {
+			data, _ := json.Marshal(app)
+			fmt.Println(string(data))
+			return
+		}
+		fmt.Printf("%s/%s\t%d\n", app.Package, app.Activity, app.Pid)
+	}
+	if !ctx.Bool("watch") {
+		app, err := device.CurrentApp()
+		if err != nil {
+			return err
+		}
+		printApp(app)
+		return nil
+	}
+	var last adb.ForegroundApp
+	for {
+		app, err := device.CurrentApp()
+		if err != nil {
+			if debug {
+				log.Println("current app:", err)
+			}
+		} else if *app != last {
+			printApp(app)
+			last = *app
+		}
+		time.Sleep(ctx.Duration("interval"))
+	}
+}
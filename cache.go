@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cavaliercoder/grab"
+	"github.com/pkg/errors"
+)
+
+// downloadCache keeps url downloads under fa directory of user cache dir, eg: ~/.cache/fa
+// Files are named by <url prefix>-sha256(url + ETag + Last-Modified), so a changed
+// artifact on server will get a new cache entry.
+// sha256(url + checksum) is used when server provides no validators but --sha256 is given.
+type downloadCache struct {
+	Dir     string
+	MaxSize int64 // bytes, <= 0 means no limit
+}
+
+func newDownloadCache(maxSize int64) (*downloadCache, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(cacheDir, "fa")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &downloadCache{
+		Dir:     dir,
+		MaxSize: maxSize,
+	}, nil
+}
+
+// validators returns ETag and Last-Modified of url, both are empty if server does not provide them.
+// Some servers reject HEAD (403, 405), so GET is tried and body is not read
+func (c *downloadCache) validators(url string) (etag, lastModified string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			return
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if debug {
+				fmt.Println("Get validators:", err)
+			}
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		}
+		if debug {
+			fmt.Printf("Get validators: %s %s: %s\n", method, url, resp.Status)
+		}
+	}
+	return "", ""
+}
+
+func (c *downloadCache) key(url string, parts ...string) string {
+	h := sha256.New()
+	io.WriteString(h, url+"\n"+strings.Join(parts, "\n"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns local path of url content, download it when not cached.
+// sum is the expected sha256 checksum, skip check if sum is empty
+func (c *downloadCache) Get(url string, sum []byte) (path string, err error) {
+	etag, lastModified := c.validators(url)
+	var key string
+	switch {
+	case etag != "" || lastModified != "":
+		key = c.key(url, etag, lastModified)
+	case len(sum) > 0:
+		// checksum identifies the content when server provides no validators
+		key = c.key(url, "sha256:"+hex.EncodeToString(sum))
+	default:
+		key = c.key(url, "", "")
+	}
+	prefix := c.key(url)[:16]
+	path = filepath.Join(c.Dir, prefix+"-"+key+".apk")
+	partPath := path + ".part"
+	// partial file downloaded before validators changed can not be resumed
+	c.removeStaleParts(prefix, partPath)
+
+	// without validators and checksum nothing tells whether the cached file is outdated
+	if etag == "" && lastModified == "" && len(sum) == 0 {
+		os.Remove(path)
+		os.Remove(partPath)
+	}
+
+	if _, err = os.Stat(path); err == nil {
+		if err = verifySha256(path, sum); err == nil {
+			if debug {
+				fmt.Println("Use cached", path)
+			}
+			now := time.Now()
+			os.Chtimes(path, now, now) // mark recently used
+			return path, nil
+		}
+		fmt.Println("Remove cached file:", err)
+		os.Remove(path)
+	}
+
+	// partial file is resumed by grab when server supports range request
+	resp, err := httpDownload(partPath, url, sum)
+	if err != nil {
+		// partial file is kept for resuming, unless its content is wrong
+		if errors.Cause(err) == grab.ErrBadChecksum {
+			os.Remove(partPath)
+		}
+		return "", err
+	}
+	if err = os.Rename(resp.Filename, path); err != nil {
+		return "", err
+	}
+	return path, c.evict(path)
+}
+
+// removeStaleParts removes partial files of url prefix except keep
+func (c *downloadCache) removeStaleParts(prefix, keep string) {
+	names, _ := filepath.Glob(filepath.Join(c.Dir, prefix+"-*.part"))
+	for _, name := range names {
+		if name != keep {
+			os.Remove(name)
+		}
+	}
+}
+
+// evict removes least recently used files until cache size less than MaxSize
+func (c *downloadCache) evict(keep string) error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+	finfos, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+	var total int64
+	entries := make([]os.FileInfo, 0, len(finfos))
+	for _, fi := range finfos {
+		if fi.IsDir() {
+			continue
+		}
+		total += fi.Size()
+		entries = append(entries, fi)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+	for _, fi := range entries {
+		if total <= c.MaxSize {
+			break
+		}
+		path := filepath.Join(c.Dir, fi.Name())
+		if path == keep {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if debug {
+			fmt.Println("Evict cached", path)
+		}
+		total -= fi.Size()
+	}
+	return nil
+}
+
+func parseSha256(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	sum, err := hex.DecodeString(strings.ToLower(s))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, errors.New("invalid sha256 checksum: " + s)
+	}
+	return sum, nil
+}
+
+func verifySha256(path string, sum []byte) error {
+	if len(sum) == 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if actual := h.Sum(nil); !bytes.Equal(actual, sum) {
+		return fmt.Errorf("sha256 mismatch, expect %x, got %x", sum, actual)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, maxSize int64) (c *downloadCache, cleanup func()) {
+	dir, err := ioutil.TempDir("", "fa-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &downloadCache{Dir: dir, MaxSize: maxSize}, func() { os.RemoveAll(dir) }
+}
+
+// writeCacheFile create file with size and modification time
+func writeCacheFile(t *testing.T, c *downloadCache, name string, size int, mtime time.Time) string {
+	path := filepath.Join(c.Dir, name)
+	if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, mtime, mtime)
+	return path
+}
+
+func listCacheDir(c *downloadCache) []string {
+	finfos, _ := ioutil.ReadDir(c.Dir)
+	names := make([]string, 0, len(finfos))
+	for _, fi := range finfos {
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDownloadCacheKey(t *testing.T) {
+	c := &downloadCache{}
+	url := "http://example.org/app.apk"
+	for _, tc := range []struct {
+		a, b  []string
+		equal bool
+	}{
+		{[]string{`"v1"`, ""}, []string{`"v1"`, ""}, true},
+		{[]string{`"v1"`, ""}, []string{`"v2"`, ""}, false},
+		{[]string{"", "Mon, 01 Jan 2024 00:00:00 GMT"}, []string{"", "Tue, 02 Jan 2024 00:00:00 GMT"}, false},
+		{[]string{`"v1"`, ""}, []string{"", `"v1"`}, false},
+		{[]string{"sha256:00"}, []string{"sha256:01"}, false},
+	} {
+		if equal := c.key(url, tc.a...) == c.key(url, tc.b...); equal != tc.equal {
+			t.Errorf("key(%q) == key(%q) is %v, expect %v", tc.a, tc.b, equal, tc.equal)
+		}
+	}
+	if c.key(url, `"v1"`) == c.key(url+"?v=2", `"v1"`) {
+		t.Error("key of different url should be different")
+	}
+}
+
+func TestDownloadCacheEvict(t *testing.T) {
+	c, cleanup := newTestCache(t, 250)
+	defer cleanup()
+	now := time.Now()
+	writeCacheFile(t, c, "old.apk", 100, now.Add(-3*time.Hour))
+	keep := writeCacheFile(t, c, "kept.apk", 100, now.Add(-2*time.Hour))
+	writeCacheFile(t, c, "recent.apk", 100, now.Add(-time.Hour))
+	writeCacheFile(t, c, "new.apk", 100, now)
+
+	// old.apk and recent.apk are removed in LRU order, kept.apk is skipped
+	if err := c.evict(keep); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"kept.apk", "new.apk"}
+	if names := listCacheDir(c); strings.Join(names, ",") != strings.Join(expect, ",") {
+		t.Errorf("expect %v, got %v", expect, names)
+	}
+
+	c.MaxSize = 0 // no limit
+	writeCacheFile(t, c, "more.apk", 1000, now)
+	c.evict("")
+	if names := listCacheDir(c); len(names) != 3 {
+		t.Errorf("nothing should be evicted without limit, got %v", names)
+	}
+}
+
+func TestDownloadCacheRemoveStaleParts(t *testing.T) {
+	c, cleanup := newTestCache(t, 0)
+	defer cleanup()
+	now := time.Now()
+	prefix := c.key("http://example.org/app.apk")[:16]
+	writeCacheFile(t, c, prefix+"-old.apk.part", 10, now)
+	current := writeCacheFile(t, c, prefix+"-new.apk.part", 10, now)
+	writeCacheFile(t, c, prefix+"-old.apk", 10, now)
+	writeCacheFile(t, c, "0000000000000000-other.apk.part", 10, now)
+
+	c.removeStaleParts(prefix, current)
+	expect := []string{"0000000000000000-other.apk.part", prefix + "-new.apk.part", prefix + "-old.apk"}
+	sort.Strings(expect)
+	if names := listCacheDir(c); strings.Join(names, ",") != strings.Join(expect, ",") {
+		t.Errorf("expect %v, got %v", expect, names)
+	}
+}
+
+func TestParseSha256(t *testing.T) {
+	valid := strings.Repeat("ab", sha256.Size)
+	for _, tc := range []struct {
+		s     string
+		size  int
+		valid bool
+	}{
+		{"", 0, true},
+		{valid, sha256.Size, true},
+		{strings.ToUpper(valid), sha256.Size, true},
+		{valid[:62], 0, false},
+		{valid + "ab", 0, false},
+		{strings.Repeat("zz", sha256.Size), 0, false},
+	} {
+		sum, err := parseSha256(tc.s)
+		if (err == nil) != tc.valid || len(sum) != tc.size {
+			t.Errorf("parseSha256(%q) = %x, %v", tc.s, sum, err)
+		}
+	}
+}
+
+func TestVerifySha256(t *testing.T) {
+	c, cleanup := newTestCache(t, 0)
+	defer cleanup()
+	path := filepath.Join(c.Dir, "a.apk")
+	ioutil.WriteFile(path, []byte("hello"), 0644)
+	sum := sha256.Sum256([]byte("hello"))
+	other := sha256.Sum256([]byte("world"))
+	for _, tc := range []struct {
+		path  string
+		sum   []byte
+		valid bool
+	}{
+		{path, sum[:], true},
+		{path, nil, true},
+		{path, other[:], false},
+		{filepath.Join(c.Dir, "missing.apk"), sum[:], false},
+	} {
+		if err := verifySha256(tc.path, tc.sum); (err == nil) != tc.valid {
+			t.Errorf("verifySha256(%s, %x): %v", filepath.Base(tc.path), tc.sum, err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/codeskyblue/fa/adb"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// socketSpec convert port number to tcp:<port>, other specs are returned as is
+func socketSpec(s string) string {
+	if regexp.MustCompile(`^\d+$`).MatchString(s) {
+		return "tcp:" + s
+	}
+	return s
+}
+
+func printForwards(forwards []adb.Forward, asJSON bool) {
+	if asJSON {
+		printJSON(forwards)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERIAL\tLOCAL\tREMOTE")
+	for _, f := range forwards {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Serial, f.Local, f.Remote)
+	}
+	w.Flush()
+}
+
+func actForward(ctx *cli.Context) error {
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	reverse := ctx.Bool("reverse")
+	switch {
+	case ctx.Bool("list"):
+		var forwards []adb.Forward
+		if reverse {
+			forwards, err = device.ListReverses()
+		} else {
+			forwards, err = device.ListForwards()
+		}
+		if err != nil {
+			return err
+		}
+		printForwards(forwards, ctx.Bool("json"))
+		return nil
+	case ctx.Bool("remove-all"):
+		if reverse {
+			return device.RemoveAllReverses()
+		}
+		return device.RemoveAllForwards()
+	case ctx.String("remove") != "":
+		if reverse {
+			return device.RemoveReverse(socketSpec(ctx.String("remove")))
+		}
+		return device.RemoveForward(socketSpec(ctx.String("remove")))
+	}
+
+	if len(ctx.Args()) != 2 {
+		return errors.New("local and remote socket are required")
+	}
+	from, to := socketSpec(ctx.Args().Get(0)), socketSpec(ctx.Args().Get(1))
+	var port int
+	if reverse {
+		port, err = device.Reverse(from, to)
+	} else {
+		port, err = device.Forward(from, to)
+	}
+	if err != nil {
+		return err
+	}
+	// print allocated port for scripts, eg: PORT=$(fa forward tcp:0 tcp:7912)
+	if port != 0 {
+		fmt.Println(port)
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 module github.com/codeskyblue/fa
 
+go 1.13
+
 require (
 	github.com/cavaliercoder/grab v2.0.0+incompatible
 	github.com/fatih/color v1.7.0 // indirect
@@ -9,7 +11,7 @@ require (
 	github.com/mattn/go-runewidth v0.0.3 // indirect
 	github.com/mattn/go-tty v0.0.0-20181127064339-e4f871175a2f
 	github.com/pkg/browser v0.0.0-20180916011732-0a3d74bf9ce4
-	github.com/pkg/errors v0.8.0
+	github.com/pkg/errors v0.9.1
 	github.com/qiniu/log v0.0.0-20140728010919-a304a74568d6
 	github.com/shogo82148/androidbinary v0.0.0-20180627093851-01c4bfa8b3b5
 	github.com/stretchr/testify v1.2.2
@@ -19,4 +21,5 @@ require (
 	gopkg.in/cheggaaa/pb.v1 v1.0.27
 	gopkg.in/resty.v1 v1.10.1
 	gopkg.in/urfave/cli.v1 v1.20.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+const (
+	healthPass = "pass"
+	healthWarn = "warn"
+	healthFail = "fail"
+)
+
+// healthResult is the outcome of one check
+type healthResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Message  string        `json:"message"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"duration"`
+}
+
+// healthTarget is the device under check
+type healthTarget struct {
+	serial string
+	device *adb.Device
+	ctx    *cli.Context
+}
+
+// healthCheck returns status and message, error is reported as failure.
+// Run is cancelled after Timeout unless --check-timeout is given
+type healthCheck struct {
+	Name    string
+	Run     func(ctx context.Context, t *healthTarget) (status string, message string, err error)
+	Timeout time.Duration
+}
+
+var healthChecks = []healthCheck{
+	{"adb", checkAdb, 10 * time.Second},
+	{"battery", checkBattery, 30 * time.Second},
+	{"storage", checkStorage, 30 * time.Second},
+	{"screen", checkScreen, 30 * time.Second},
+	{"network", checkNetwork, 30 * time.Second},
+	{"uptime", checkUptime, 30 * time.Second},
+	{"packages", checkPackages, 30 * time.Second},
+	{"install", checkInstall, 2 * time.Minute},
+}
+
+func checkAdb(ctx context.Context, t *healthTarget) (string, string, error) {
+	start := time.Now()
+	output, err := t.device.RunCommandContext(ctx, "echo", "fa-healthcheck")
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(output) != "fa-healthcheck" {
+		return healthFail, fmt.Sprintf("unexpected shell output: %q", output), nil
+	}
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if elapsed > 2*time.Second {
+		return healthWarn, fmt.Sprintf("shell responds slowly in %v", elapsed), nil
+	}
+	return healthPass, fmt.Sprintf("shell responds in %v", elapsed), nil
+}
+
+func checkBattery(ctx context.Context, t *healthTarget) (string, string, error) {
+	info, err := t.device.BatteryContext(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	message := fmt.Sprintf("level %d%%, temperature %.1f°C", info.Percent(), info.Temperature)
+	if info.Percent() < t.ctx.Int("min-battery") {
+		return healthFail, message, nil
+	}
+	if info.Temperature > t.ctx.Float64("max-temperature") {
+		return healthWarn, message, nil
+	}
+	return healthPass, message, nil
+}
+
+func checkStorage(ctx context.Context, t *healthTarget) (string, string, error) {
+	usage, err := t.device.DiskUsageContext(ctx, "/data")
+	if err != nil {
+		return "", "", err
+	}
+	freeMB := usage.Available >> 20
+	message := fmt.Sprintf("%d MB free on /data", freeMB)
+	if freeMB < int64(t.ctx.Int("min-storage")) {
+		return healthFail, message, nil
+	}
+	return healthPass, message, nil
+}
+
+func checkScreen(ctx context.Context, t *healthTarget) (string, string, error) {
+	on, err := t.device.IsScreenOnContext(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if !on {
+		return healthWarn, "screen is off", nil
+	}
+	locked, err := t.device.IsLockedContext(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if locked {
+		return healthWarn, "screen is on but locked", nil
+	}
+	return healthPass, "screen is on and unlocked", nil
+}
+
+func checkNetwork(ctx context.Context, t *healthTarget) (string, string, error) {
+	host := t.ctx.String("ping-host")
+	output, err := t.device.RunCommandContext(ctx, "ping", "-c", "1", "-W", "5", host)
+	if err != nil {
+		return "", "", err
+	}
+	if !strings.Contains(output, " 0% packet loss") {
+		return healthFail, "ping " + host + " failed", nil
+	}
+	return healthPass, "ping " + host + " ok", nil
+}
+
+func checkUptime(ctx context.Context, t *healthTarget) (string, string, error) {
+	uptime, err := t.device.UptimeContext(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	uptime = uptime.Round(time.Second)
+	if maxUptime := t.ctx.Duration("max-uptime"); maxUptime > 0 && uptime > maxUptime {
+		return healthWarn, fmt.Sprintf("up %v, reboot is recommended", uptime), nil
+	}
+	return healthPass, fmt.Sprintf("up %v", uptime), nil
+}
+
+func checkPackages(ctx context.Context, t *healthTarget) (string, string, error) {
+	packages, err := t.device.ListPackagesContext(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	required := []string{"android", "com.android.shell"}
+	for _, name := range required {
+		found := false
+		for _, p := range packages {
+			if p == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return healthFail, "system package " + name + " not found", nil
+		}
+	}
+	thirdParty, err := t.device.ListPackagesContext(ctx, "-3")
+	if err != nil {
+		return "", "", err
+	}
+	return healthPass, fmt.Sprintf("%d packages, %d third-party", len(packages), len(thirdParty)), nil
+}
+
+// checkInstall install and uninstall the embedded apk
+func checkInstall(ctx context.Context, t *healthTarget) (string, string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.Replace(healthcheckApkBase64, "\n", "", -1))
+	if err != nil {
+		return "", "", err
+	}
+	tmpfile, err := ioutil.TempFile("", "fa-healthcheck-*.apk")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.Write(data)
+	tmpfile.Close()
+	if err != nil {
+		return "", "", err
+	}
+
+	start := time.Now()
+	if err := t.device.InstallContext(ctx, tmpfile.Name(), "-r"); err != nil {
+		return healthFail, err.Error(), nil
+	}
+	if err := t.device.AppUninstallContext(ctx, healthcheckPackage, false); err != nil {
+		return healthFail, "uninstall failed: " + err.Error(), nil
+	}
+	return healthPass, fmt.Sprintf("install and uninstall in %v", time.Since(start).Round(time.Millisecond)), nil
+}
+
+// selectHealthChecks returns checks given by --check, excluding --skip
+func selectHealthChecks(only, skip []string) ([]healthCheck, error) {
+	known := make(map[string]bool)
+	for _, c := range healthChecks {
+		known[c.Name] = true
+	}
+	for _, name := range append(append([]string{}, only...), skip...) {
+		if !known[name] {
+			return nil, errors.New("unknown check: " + name)
+		}
+	}
+	contains := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	checks := make([]healthCheck, 0, len(healthChecks))
+	for _, c := range healthChecks {
+		if len(only) > 0 && !contains(only, c.Name) {
+			continue
+		}
+		if contains(skip, c.Name) {
+			continue
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// runHealthCheck run c with timeout, timeout is reported as failure
+func runHealthCheck(c healthCheck, t *healthTarget, timeout time.Duration) (status, message string) {
+	if timeout <= 0 {
+		timeout = c.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	status, message, err := c.Run(ctx, t)
+	if ctx.Err() == context.DeadlineExceeded {
+		return healthFail, fmt.Sprintf("timeout after %v", timeout)
+	}
+	if err != nil {
+		return healthFail, err.Error()
+	}
+	return status, message
+}
+
+func runHealthChecks(t *healthTarget, checks []healthCheck, timeout time.Duration) []healthResult {
+	results := make([]healthResult, 0, len(checks))
+	for _, c := range checks {
+		start := time.Now()
+		status, message := runHealthCheck(c, t, timeout)
+		r := healthResult{
+			Name:     c.Name,
+			Status:   status,
+			Message:  message,
+			Duration: time.Since(start),
+		}
+		r.Seconds = r.Duration.Seconds()
+		results = append(results, r)
+		// other checks are meaningless when device not responding
+		if c.Name == "adb" && status == healthFail {
+			break
+		}
+	}
+	return results
+}
+
+func printHealthResults(results []healthResult, asJSON bool) {
+	if asJSON {
+		printJSON(results)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDURATION\tMESSAGE")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", r.Name, strings.ToUpper(r.Status), r.Duration.Round(time.Millisecond), r.Message)
+	}
+	w.Flush()
+}
+
+func actHealthcheck(ctx *cli.Context) error {
+	checks, err := selectHealthChecks(ctx.StringSlice("check"), ctx.StringSlice("skip"))
+	if err != nil {
+		return err
+	}
+	serial, err := chooseOne()
+	if err != nil {
+		return err
+	}
+	target := &healthTarget{serial: serial, device: newDevice(serial), ctx: ctx}
+	results := runHealthChecks(target, checks, ctx.Duration("check-timeout"))
+	printHealthResults(results, ctx.Bool("json"))
+	failed := 0
+	for _, r := range results {
+		if r.Status == healthFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
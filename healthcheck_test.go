@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHealthCheckTimeout(t *testing.T) {
+	hang := healthCheck{"hang", func(ctx context.Context, t *healthTarget) (string, string, error) {
+		<-ctx.Done()
+		return "", "", ctx.Err()
+	}, time.Minute}
+	start := time.Now()
+	status, message := runHealthCheck(hang, &healthTarget{}, 50*time.Millisecond)
+	if status != healthFail || !strings.Contains(message, "timeout") {
+		t.Errorf("expect timeout failure, got %s: %s", status, message)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("check is not cancelled in time")
+	}
+
+	pass := healthCheck{"pass", func(ctx context.Context, t *healthTarget) (string, string, error) {
+		return healthPass, "ok", nil
+	}, time.Second}
+	if status, _ := runHealthCheck(pass, &healthTarget{}, 0); status != healthPass {
+		t.Errorf("expect pass, got %s", status)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatchHooksBackgroundChild(t *testing.T) {
+	for _, tc := range []struct {
+		command string
+		timeout time.Duration
+	}{
+		{"sleep 30 & echo started; wait", 500 * time.Millisecond}, // killed with the background child
+		{"sleep 30; echo done", 500 * time.Millisecond},
+		{"sleep 30 & echo done", 0}, // hook exited, the background child holds output pipe
+	} {
+		h := &watchHooks{
+			timeout:     tc.timeout,
+			concurrency: make(chan struct{}, 1),
+		}
+		start := time.Now()
+		h.run("test", tc.command, os.Environ())
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("hook %q returned after %v", tc.command, elapsed)
+		}
+		select {
+		case h.concurrency <- struct{}{}:
+		default:
+			t.Errorf("hook %q holds concurrency slot", tc.command)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import "os/exec"
+
+// setHookProcessGroup is not supported on windows, only the hook process is killed on timeout
+func setHookProcessGroup(cmd *exec.Cmd) {}
+
+func killHookProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// inputStep is one step of input replay script, only one action should be set
+//
+//   - tap: [100, 200]
+//   - swipe: [100, 800, 100, 200]
+//     duration: 300ms
+//   - longpress: [100, 200]
+//   - key: HOME
+//   - text: hello world
+//   - wait: 1s
+type inputStep struct {
+	Tap       []int  `json:"tap,omitempty" yaml:"tap"`
+	Swipe     []int  `json:"swipe,omitempty" yaml:"swipe"`
+	LongPress []int  `json:"longpress,omitempty" yaml:"longpress"`
+	Key       string `json:"key,omitempty" yaml:"key"` // space separated key codes
+	Text      string `json:"text,omitempty" yaml:"text"`
+	Duration  string `json:"duration,omitempty" yaml:"duration"` // swipe and longpress duration
+	Wait      string `json:"wait,omitempty" yaml:"wait"`         // sleep after action
+}
+
+func parseDuration(s string, defaultValue time.Duration) (time.Duration, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (step inputStep) run(device *adb.Device) error {
+	duration, err := parseDuration(step.Duration, 0)
+	if err != nil {
+		return err
+	}
+	switch {
+	case step.Tap != nil:
+		if len(step.Tap) != 2 {
+			return errors.New("tap require [x, y]")
+		}
+		err = device.Tap(step.Tap[0], step.Tap[1])
+	case step.Swipe != nil:
+		if len(step.Swipe) != 4 {
+			return errors.New("swipe require [x1, y1, x2, y2]")
+		}
+		err = device.Swipe(step.Swipe[0], step.Swipe[1], step.Swipe[2], step.Swipe[3], duration)
+	case step.LongPress != nil:
+		if len(step.LongPress) != 2 {
+			return errors.New("longpress require [x, y]")
+		}
+		err = device.LongPress(step.LongPress[0], step.LongPress[1], duration)
+	case step.Key != "":
+		err = device.KeyEvent(strings.Fields(step.Key)...)
+	case step.Text != "":
+		err = device.Text(step.Text)
+	}
+	if err != nil {
+		return err
+	}
+	wait, err := parseDuration(step.Wait, 0)
+	if err != nil {
+		return err
+	}
+	time.Sleep(wait)
+	return nil
+}
+
+// loadInputScript read steps from .json or .yml file
+func loadInputScript(path string) (steps []inputStep, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &steps)
+	} else {
+		err = yaml.Unmarshal(data, &steps)
+	}
+	return steps, errors.Wrap(err, "parse "+path)
+}
+
+func intArgs(ctx *cli.Context, n int) (values []int, err error) {
+	if len(ctx.Args()) != n {
+		return nil, fmt.Errorf("%d arguments required", n)
+	}
+	for _, arg := range ctx.Args() {
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return
+}
+
+func actInputTap(ctx *cli.Context) error {
+	pos, err := intArgs(ctx, 2)
+	if err != nil {
+		return err
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	return device.Tap(pos[0], pos[1])
+}
+
+func actInputSwipe(ctx *cli.Context) error {
+	pos, err := intArgs(ctx, 4)
+	if err != nil {
+		return err
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	return device.Swipe(pos[0], pos[1], pos[2], pos[3], ctx.Duration("duration"))
+}
+
+func actInputLongPress(ctx *cli.Context) error {
+	pos, err := intArgs(ctx, 2)
+	if err != nil {
+		return err
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	return device.LongPress(pos[0], pos[1], ctx.Duration("duration"))
+}
+
+func actInputKey(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return errors.New("key code should provided")
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	return device.KeyEvent(ctx.Args()...)
+}
+
+func actInputText(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return errors.New("text should provided")
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	return device.Text(strings.Join(ctx.Args(), " "))
+}
+
+func actInputReplay(ctx *cli.Context) error {
+	if !ctx.Args().Present() {
+		return errors.New("script file should provided")
+	}
+	steps, err := loadInputScript(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < ctx.Int("repeat"); i++ {
+		for j, step := range steps {
+			if debug {
+				data, _ := json.Marshal(step)
+				fmt.Printf("step %d: %s\n", j+1, data)
+			}
+			if err := step.run(device); err != nil {
+				return errors.Wrapf(err, "step %d", j+1)
+			}
+		}
+	}
+	return nil
+}
@@ -2,26 +2,34 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
-	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/cavaliercoder/grab"
+	"github.com/codeskyblue/fa/adb"
 	"github.com/pkg/errors"
 	"github.com/shogo82148/androidbinary/apk"
 	pb "gopkg.in/cheggaaa/pb.v1"
 	cli "gopkg.in/urfave/cli.v1"
 )
 
-func httpDownload(dst string, url string) (resp *grab.Response, err error) {
+// httpDownload save url to dst, sum is optional sha256 checksum
+func httpDownload(dst string, url string, sum []byte) (resp *grab.Response, err error) {
 	client := grab.NewClient()
 	req, err := grab.NewRequest(dst, url)
 	if err != nil {
 		return nil, err
 	}
+	if len(sum) > 0 {
+		req.SetChecksum(sha256.New(), sum, true)
+	}
 	// start download
 	resp = client.Do(req)
 	fmt.Printf("Downloading %v...\n", resp.Filename)
@@ -56,24 +64,169 @@ Loop:
 	return resp, err
 }
 
+// installResult is the per device outcome of a batch install
+type installResult struct {
+	Serial      string        `json:"serial"`
+	Success     bool          `json:"success"`
+	Duration    time.Duration `json:"-"`
+	Seconds     float64       `json:"duration"`
+	FailureCode string        `json:"failureCode,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// installApk install apkpath to device, install output is written to w
+func installApk(serial string, pkg *apk.Apk, apkpath string, force, launch, wait bool, w io.Writer) error {
+	device := newDevice(serial)
+	// handle --force
+	if force {
+		device.AppUninstall(pkg.PackageName(), false)
+	}
+
+	// install
+	fmt.Fprintln(w, "Install", filepath.Base(apkpath), "...")
+	if err := device.Install(apkpath, "-r"); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Success")
+	if launch {
+		mainActivity, _ := pkg.MainActivity()
+		_, err := launchApp(device, pkg.PackageName(), mainActivity, wait, w)
+		return err
+	}
+	return nil
+}
+
+// installSerials returns the serials given by --all or --serials, devices are listed with listDevices for --all
+func installSerials(all bool, serials []string, listDevices func() ([]Device, error)) ([]string, error) {
+	if !all {
+		return serials, nil
+	}
+	if len(serials) > 0 {
+		return nil, errors.New("--all and --serials can not be used together")
+	}
+	devices, err := listDevices()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		serials = append(serials, d.Serial)
+	}
+	if len(serials) == 0 {
+		return nil, errors.New("no devices/emulators found")
+	}
+	return serials, nil
+}
+
+// batchInstall call install for all serials concurrently, output of install is printed on failure with --debug
+func batchInstall(serials []string, install func(serial string, w io.Writer) error) []installResult {
+	results := make([]installResult, len(serials))
+	wg := sync.WaitGroup{}
+	for i, serial := range serials {
+		wg.Add(1)
+		go func(i int, serial string) {
+			defer wg.Done()
+			start := time.Now()
+			output := bytes.NewBuffer(nil)
+			err := install(serial, output)
+			r := installResult{
+				Serial:   serial,
+				Success:  err == nil,
+				Duration: time.Since(start),
+			}
+			r.Seconds = r.Duration.Round(time.Millisecond).Seconds()
+			if err != nil {
+				r.Error = err.Error()
+				if ierr, ok := err.(*adb.InstallError); ok {
+					r.FailureCode = ierr.Code
+				}
+				if debug {
+					fmt.Printf("%s output:\n%s", serial, output.String())
+				}
+			}
+			results[i] = r
+		}(i, serial)
+	}
+	wg.Wait()
+	return results
+}
+
+// installResultsError returns error if any install failed
+func installResultsError(results []installResult) error {
+	failed := 0
+	for _, r := range results {
+		if !r.Success {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d installs failed", failed, len(results))
+	}
+	return nil
+}
+
+func printInstallResults(results []installResult, asJSON bool) {
+	if asJSON {
+		printJSON(results)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERIAL\tRESULT\tDURATION\tFAILURE")
+	for _, r := range results {
+		result := "success"
+		if !r.Success {
+			result = "failure"
+		}
+		failure := r.FailureCode
+		if failure == "" {
+			failure = r.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", r.Serial, result, r.Duration.Round(time.Millisecond), failure)
+	}
+	w.Flush()
+}
+
 func actInstall(ctx *cli.Context) error {
 	if !ctx.Args().Present() {
 		return errors.New("apkfile or apkurl should provided")
 	}
-	serial, err := chooseOne()
+	serials, err := installSerials(ctx.Bool("all"), ctx.StringSlice("serials"), listOnlineDevices)
 	if err != nil {
 		return err
 	}
+	if len(serials) == 0 {
+		serial, err := chooseOne()
+		if err != nil {
+			return err
+		}
+		serials = []string{serial}
+	}
 	arg := ctx.Args().First()
 
+	sum, err := parseSha256(ctx.String("sha256"))
+	if err != nil {
+		return err
+	}
+
 	// download apk
 	apkpath := arg
 	if regexp.MustCompile(`^https?://`).MatchString(arg) {
-		resp, err := httpDownload(".", arg)
-		if err != nil {
-			return err
+		if ctx.Bool("no-cache") {
+			resp, err := httpDownload(".", arg, sum)
+			if err != nil {
+				return err
+			}
+			apkpath = resp.Filename
+		} else {
+			cache, err := newDownloadCache(int64(ctx.Int("cache-size")) << 20)
+			if err != nil {
+				return err
+			}
+			if apkpath, err = cache.Get(arg, sum); err != nil {
+				return err
+			}
 		}
-		apkpath = resp.Filename
+	} else if err := verifySha256(apkpath, sum); err != nil {
+		return err
 	}
 
 	// parse apk
@@ -82,37 +235,14 @@ func actInstall(ctx *cli.Context) error {
 		return err
 	}
 
-	// handle --force
-	if ctx.Bool("force") {
-		pkgName := pkg.PackageName()
-		adbCommand(serial, "uninstall", pkgName).Run()
+	force, launch, wait := ctx.Bool("force"), ctx.Bool("launch"), ctx.Bool("wait")
+	if len(serials) == 1 && !ctx.Bool("json") {
+		return installApk(serials[0], pkg, apkpath, force, launch, wait, os.Stdout)
 	}
 
-	// install
-	outBuffer := bytes.NewBuffer(nil)
-	c := adbCommand(serial, "install", "-r", apkpath)
-	c.Stdout = io.MultiWriter(os.Stdout, outBuffer)
-	c.Stderr = os.Stderr
-
-	if err := c.Run(); err != nil {
-		return err
-	}
-
-	if strings.Contains(outBuffer.String(), "Failure") {
-		return errors.New("install failed")
-	}
-	if ctx.Bool("launch") {
-		packageName := pkg.PackageName()
-		mainActivity, er := pkg.MainActivity()
-		if er != nil {
-			fmt.Println("apk have no main-activity")
-			return nil
-		}
-		if !strings.Contains(mainActivity, ".") {
-			mainActivity = "." + mainActivity
-		}
-		fmt.Println("Launch app", packageName, "...")
-		adbCommand(serial, "shell", "am", "start", "-n", packageName+"/"+mainActivity).Run()
-	}
-	return nil
+	results := batchInstall(serials, func(serial string, w io.Writer) error {
+		return installApk(serial, pkg, apkpath, force, launch, wait, w)
+	})
+	printInstallResults(results, ctx.Bool("json"))
+	return installResultsError(results)
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/codeskyblue/fa/adb"
+)
+
+func TestInstallSerials(t *testing.T) {
+	online := func() ([]Device, error) {
+		return []Device{{Serial: "a"}, {Serial: "b"}}, nil
+	}
+	none := func() ([]Device, error) { return nil, nil }
+	failed := func() ([]Device, error) { return nil, errors.New("adb server not available") }
+	for _, tc := range []struct {
+		all     bool
+		serials []string
+		list    func() ([]Device, error)
+		expect  []string
+		err     string
+	}{
+		{false, nil, online, nil, ""},
+		{false, []string{"c", "d"}, online, []string{"c", "d"}, ""},
+		{true, nil, online, []string{"a", "b"}, ""},
+		{true, []string{"c"}, online, nil, "can not be used together"},
+		{true, nil, none, nil, "no devices"},
+		{true, nil, failed, nil, "adb server"},
+	} {
+		serials, err := installSerials(tc.all, tc.serials, tc.list)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("all=%v serials=%v: expect error %q, got %v", tc.all, tc.serials, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(serials, tc.expect) {
+			t.Errorf("all=%v serials=%v: expect %v, got %v, %v", tc.all, tc.serials, tc.expect, serials, err)
+		}
+	}
+}
+
+func TestBatchInstall(t *testing.T) {
+	errs := map[string]error{
+		"b": &adb.InstallError{Code: "INSTALL_FAILED_INSUFFICIENT_STORAGE"},
+		"c": errors.New("device offline"),
+	}
+	serials := []string{"a", "b", "c", "d"}
+	results := batchInstall(serials, func(serial string, w io.Writer) error {
+		fmt.Fprintln(w, "Install", serial)
+		return errs[serial]
+	})
+	if len(results) != len(serials) {
+		t.Fatalf("expect %d results, got %d", len(serials), len(results))
+	}
+	var failures []string
+	for i, r := range results {
+		if r.Serial != serials[i] {
+			t.Errorf("result %d: expect serial %s, got %s", i, serials[i], r.Serial)
+		}
+		if r.Success != (errs[r.Serial] == nil) {
+			t.Errorf("%s: unexpected success %v", r.Serial, r.Success)
+		}
+		if !r.Success {
+			failures = append(failures, r.Serial+":"+r.FailureCode+":"+r.Error)
+		}
+	}
+	sort.Strings(failures)
+	expect := []string{
+		"b:INSTALL_FAILED_INSUFFICIENT_STORAGE:" + errs["b"].Error(),
+		"c::device offline",
+	}
+	if !reflect.DeepEqual(failures, expect) {
+		t.Errorf("expect failures %v, got %v", expect, failures)
+	}
+	if err := installResultsError(results); err == nil || err.Error() != "2 of 4 installs failed" {
+		t.Errorf("expect 2 of 4 installs failed, got %v", err)
+	}
+	if err := installResultsError(results[:1]); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+}
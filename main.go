@@ -12,10 +12,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
@@ -46,53 +44,40 @@ func (d *Device) String() string {
 	return d.Serial
 }
 
-func shortDeviceInfo(s string) string {
-	fields := strings.Fields(s)
-	props := make(map[string]string, 4)
-	for _, v := range fields {
-		kv := strings.SplitN(v, ":", 2)
-		if len(kv) != 2 {
-			continue
-		}
-		props[kv[0]] = kv[1]
-	}
-	if props["model"] != "" {
-		return props["model"]
-	}
-	return s
+func newClient() *adb.Client {
+	return adb.NewClient(fmt.Sprintf("%s:%d", defaultHost, defaultPort))
 }
 
+// listDevices returns devices in state device, offline or unauthorized
 func listDevices() (ds []Device, err error) {
-	output, err := exec.Command("adb", "devices").CombinedOutput()
+	infos, err := newClient().ListDeviceInfos()
 	if err != nil {
 		return
 	}
-	re := regexp.MustCompile(`(?m)^([^\s]+)\s+(device|offline|unauthorized)\s*$`)
-	matches := re.FindAllStringSubmatch(string(output), -1)
-	ds = make([]Device, 0, len(matches))
-	for _, m := range matches {
-		status := m[2]
-		ds = append(ds, Device{
-			Serial: m[1],
-			Status: status,
-		})
+	ds = make([]Device, 0, len(infos))
+	for _, info := range infos {
+		switch info.State {
+		case adb.StateOnline, adb.StateOffline, adb.StateUnauthorized:
+			ds = append(ds, Device{
+				Serial:      info.Serial,
+				Status:      string(info.State),
+				Description: info.Model,
+			})
+		}
 	}
 	return
 }
 
-func listDetailedDevices() (ds []Device, err error) {
-	output, err := exec.Command("adb", "devices", "-l").CombinedOutput()
+// listOnlineDevices returns devices ready to use
+func listOnlineDevices() (ds []Device, err error) {
+	devices, err := listDevices()
 	if err != nil {
 		return
 	}
-	re := regexp.MustCompile(`(?m)^([^\s]+)\s+device\s+(.+)$`)
-	matches := re.FindAllStringSubmatch(string(output), -1)
-	for _, m := range matches {
-		desc := shortDeviceInfo(m[2])
-		ds = append(ds, Device{
-			Serial:      m[1],
-			Description: desc,
-		})
+	for _, d := range devices {
+		if d.Status == string(adb.StateOnline) {
+			ds = append(ds, d)
+		}
 	}
 	return
 }
@@ -123,18 +108,49 @@ func choose(devices []Device) Device {
 }
 
 func chooseOne() (serial string, err error) {
-	devices, err := listDetailedDevices()
+	devices, err := listOnlineDevices()
 	if err != nil {
 		return
 	}
 	if len(devices) == 0 {
-		err = errors.New("no devices/emulators found")
+		all, _ := listDevices()
+		err = noDeviceError(all)
 		return
 	}
 	d := choose(devices)
 	return d.Serial, nil
 }
 
+// noDeviceError returns ErrUnauthorized or ErrDeviceOffline when all devices seen are in that state,
+// so the hint of adbErrorHint is shown
+func noDeviceError(devices []Device) error {
+	states := make(map[string]bool)
+	for _, d := range devices {
+		states[d.Status] = true
+	}
+	if len(states) == 1 {
+		switch {
+		case states[string(adb.StateUnauthorized)]:
+			return fmt.Errorf("no devices/emulators online: %w", adb.ErrUnauthorized)
+		case states[string(adb.StateOffline)]:
+			return fmt.Errorf("no devices/emulators online: %w", adb.ErrDeviceOffline)
+		}
+	}
+	return fmt.Errorf("no devices/emulators found: %w", adb.ErrDeviceNotFound)
+}
+
+func newDevice(serial string) *adb.Device {
+	return newClient().DeviceWithSerial(serial)
+}
+
+func chooseDevice() (*adb.Device, error) {
+	serial, err := chooseOne()
+	if err != nil {
+		return nil, err
+	}
+	return newDevice(serial), nil
+}
+
 func adbWrap(args ...string) {
 	serial, err := chooseOne()
 	if err != nil {
@@ -168,6 +184,23 @@ func adbPath() string {
 	return path
 }
 
+// adbErrorHint returns what user can do to fix adb error
+func adbErrorHint(err error) string {
+	switch {
+	case errors.Is(err, adb.ErrUnauthorized):
+		return "accept the RSA prompt on the device, replug the usb cable if no prompt is shown"
+	case errors.Is(err, adb.ErrDeviceOffline):
+		return "replug the usb cable or run: adb reconnect offline"
+	case errors.Is(err, adb.ErrDeviceNotFound):
+		return "check the serial with: fa devices"
+	case errors.Is(err, adb.ErrMoreThanOneDevice):
+		return "select device with -s <serial> or ANDROID_SERIAL"
+	case errors.Is(err, adb.ErrServerNotAvailable):
+		return "check adb is in PATH, or start adb server with: adb start-server"
+	}
+	return ""
+}
+
 func main() {
 	app := cli.NewApp()
 	app.Version = version
@@ -218,13 +251,13 @@ func main() {
 			Usage: "show version",
 			Action: func(ctx *cli.Context) error {
 				fmt.Printf("fa version %s\n", version)
-				adbVersion, err := NewAdbClient().Version()
+				adbVersion, err := newClient().ServerVersion()
 				if err != nil {
 					fmt.Printf("adb version err: %v\n", err)
 					return err
 				}
 				fmt.Println("adb path", adbPath())
-				fmt.Println("adb server version", adbVersion)
+				fmt.Printf("adb server version 1.0.%d\n", adbVersion)
 				return nil
 				// output, err := exec.Command(adbPath(), "version").Output()
 				// for _, line := range strings.Split(string(output), "\n") {
@@ -278,23 +311,126 @@ func main() {
 							Name:  "3",
 							Usage: "filter to only show third party packages",
 						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
 					},
-					Action: func(ctx *cli.Context) error {
-						args := []string{"shell", "pm", "list", "packages"}
-						if ctx.Bool("s") {
-							args = append(args, "-s")
-						}
-						if ctx.Bool("f") {
-							args = append(args, "-f")
-						}
-						if ctx.Bool("3") {
-							args = append(args, "-3")
-						}
-						adbWrap(args...)
-						return nil
+					Action: actAppList,
+				},
+				{
+					Name:      "info",
+					Usage:     "show app version, installer, paths and granted permissions",
+					UsageText: "fa app info <package-name>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actAppInfo,
+				},
+				{
+					Name:      "start",
+					Usage:     "start app launcher activity",
+					UsageText: "fa app start [--wait] <package-name>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "wait, W",
+							Usage: "wait for launch complete and show launch timing",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actAppStart,
+				},
+				{
+					Name:      "stop",
+					Usage:     "force stop app",
+					UsageText: "fa app stop <package-name>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actAppStop,
+				},
+				{
+					Name:      "clear",
+					Usage:     "clear app data",
+					UsageText: "fa app clear <package-name>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actAppClear,
+				},
+				{
+					Name:      "uninstall",
+					Usage:     "uninstall app",
+					UsageText: "fa app uninstall [--keep-data] <package-name>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "keep-data, k",
+							Usage: "keep the data and cache directories",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actAppUninstall,
+				},
+				{
+					Name:      "grant",
+					Usage:     "grant runtime permissions",
+					UsageText: "fa app grant <package-name> <permission ...>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
 					},
+					Action: actAppGrant,
+				},
+				{
+					Name:      "revoke",
+					Usage:     "revoke runtime permissions",
+					UsageText: "fa app revoke <package-name> <permission ...>",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actAppRevoke,
+				},
+			},
+		},
+		{
+			Name:  "current",
+			Usage: "show current foreground app",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "watch, w",
+					Usage: "print every time the foreground app changes",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Usage: "check interval when --watch",
+					Value: time.Second,
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "output json format",
 				},
 			},
+			Action: actCurrent,
 		},
 		{
 			Name:  "screenshot",
@@ -303,7 +439,16 @@ func main() {
 				cli.StringFlag{
 					Name:  "output, o",
 					Value: "screenshot.png",
-					Usage: "output screenshot name",
+					Usage: "output screenshot name, format is chosen by extension (.png, .jpg)",
+				},
+				cli.Float64Flag{
+					Name:  "scale",
+					Usage: "scale image, eg: 0.5",
+					Value: 1.0,
+				},
+				cli.StringFlag{
+					Name:  "display",
+					Usage: "display id, see: adb shell dumpsys SurfaceFlinger --display-id",
 				},
 				cli.BoolFlag{
 					Name:  "open",
@@ -312,6 +457,342 @@ func main() {
 			},
 			Action: actScreenshot,
 		},
+		{
+			Name:  "screenrecord",
+			Usage: "record screen to mp4, stop with Ctrl-C",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output, o",
+					Value: "screenrecord.mp4",
+					Usage: "output video name",
+				},
+				cli.StringFlag{
+					Name:  "size",
+					Usage: "video size, eg: 1280x720, default is the device's main display resolution",
+				},
+				cli.IntFlag{
+					Name:  "bit-rate",
+					Usage: "video bit rate in bits per second, eg: 6000000",
+				},
+			},
+			Action: actScreenrecord,
+		},
+		{
+			Name:  "mirror",
+			Usage: "show device screen in browser, clicks and keys are forwarded to device",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "addr",
+					Usage: "listen address, use 0.0.0.0 to share with others in LAN",
+					Value: "127.0.0.1",
+				},
+				cli.IntFlag{
+					Name:  "port",
+					Usage: "listen port",
+					Value: 6180,
+				},
+				cli.Float64Flag{
+					Name:  "scale",
+					Usage: "scale screen image, eg: 0.5",
+					Value: 0.5,
+				},
+				cli.IntFlag{
+					Name:  "fps",
+					Usage: "max frames per second",
+					Value: 10,
+				},
+				cli.BoolFlag{
+					Name:  "open",
+					Usage: "open mirror page in browser",
+				},
+			},
+			Action: actMirror,
+		},
+		{
+			Name:  "input",
+			Usage: "send touch, key and text input to device",
+			Subcommands: []cli.Command{
+				{
+					Name:      "tap",
+					Usage:     "tap at position",
+					UsageText: "fa input tap <x> <y>",
+					Action:    actInputTap,
+				},
+				{
+					Name:      "swipe",
+					Usage:     "swipe from (x1, y1) to (x2, y2)",
+					UsageText: "fa input swipe [--duration 300ms] <x1> <y1> <x2> <y2>",
+					Flags: []cli.Flag{
+						cli.DurationFlag{
+							Name:  "duration",
+							Usage: "swipe duration",
+							Value: 300 * time.Millisecond,
+						},
+					},
+					Action: actInputSwipe,
+				},
+				{
+					Name:      "longpress",
+					Usage:     "long press at position",
+					UsageText: "fa input longpress [--duration 1s] <x> <y>",
+					Flags: []cli.Flag{
+						cli.DurationFlag{
+							Name:  "duration",
+							Usage: "press duration",
+							Value: time.Second,
+						},
+					},
+					Action: actInputLongPress,
+				},
+				{
+					Name:      "key",
+					Usage:     "send key events",
+					UsageText: "fa input key <HOME | BACK | 3 ...>",
+					Action:    actInputKey,
+				},
+				{
+					Name:      "text",
+					Usage:     "type text into focused view",
+					UsageText: "fa input text <text>",
+					Action:    actInputText,
+				},
+				{
+					Name:      "replay",
+					Usage:     "run steps from json or yaml script",
+					UsageText: "fa input replay [--repeat N] <script.yml | script.json>",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "repeat",
+							Usage: "repeat times",
+							Value: 1,
+						},
+					},
+					Action: actInputReplay,
+				},
+			},
+		},
+		{
+			Name:  "ui",
+			Usage: "dump view hierarchy and find ui elements",
+			Subcommands: []cli.Command{
+				{
+					Name:  "dump",
+					Usage: "dump view hierarchy of current screen",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "xml",
+							Usage: "output raw xml of uiautomator dump",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actUIDump,
+				},
+				{
+					Name:      "find",
+					Usage:     "find ui elements, exit with error if not found",
+					UsageText: "fa ui find [--text OK | --id button1 | --class Button | --desc Back] [--click]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "text",
+							Usage: "match text",
+						},
+						cli.StringFlag{
+							Name:  "text-contains",
+							Usage: "match text contains",
+						},
+						cli.StringFlag{
+							Name:  "id",
+							Usage: "match resource-id, package prefix can be omitted",
+						},
+						cli.StringFlag{
+							Name:  "class",
+							Usage: "match class name, package prefix can be omitted",
+						},
+						cli.StringFlag{
+							Name:  "desc",
+							Usage: "match content-desc",
+						},
+						cli.BoolFlag{
+							Name:  "click",
+							Usage: "tap center of the first matched element",
+						},
+						cli.DurationFlag{
+							Name:  "wait",
+							Usage: "wait element appear, eg: 10s",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output json format",
+						},
+					},
+					Action: actUIFind,
+				},
+			},
+		},
+		{
+			Name:      "forward",
+			Usage:     "forward host port to device, or reverse device port to host",
+			UsageText: "fa forward [--reverse] <local> <remote>\n   fa forward [--reverse] --list | --remove <socket> | --remove-all",
+			Description: "Socket is port number or adb socket spec, eg: 7912, tcp:0, localabstract:minicap\n" +
+				"   Local port is allocated and printed when local is tcp:0, eg: PORT=$(fa forward tcp:0 7912)\n" +
+				"   With --reverse, first argument is the socket on device",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "reverse, r",
+					Usage: "reverse socket on device to host",
+				},
+				cli.BoolFlag{
+					Name:  "list, l",
+					Usage: "list forwards of device",
+				},
+				cli.StringFlag{
+					Name:  "remove",
+					Usage: "remove forward of given local socket, or remote socket with --reverse",
+				},
+				cli.BoolFlag{
+					Name:  "remove-all",
+					Usage: "remove all forwards of device",
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "output --list in json format",
+				},
+			},
+			Action: actForward,
+		},
+		{
+			Name:      "tcpip",
+			Usage:     "switch usb device to tcp mode and connect it through wlan",
+			UsageText: "fa tcpip [--port 5555] [--export]\n   fa tcpip --pair <host:port> --code <code> [host:port]",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "port, p",
+					Usage: "tcp port adbd listen on",
+					Value: 5555,
+				},
+				cli.StringFlag{
+					Name:  "pair",
+					Usage: "pair with Android 11+ wireless debugging address, then connect to address in arguments",
+				},
+				cli.StringFlag{
+					Name:  "code",
+					Usage: "pairing code shown in wireless debugging",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "wait timeout for device online",
+					Value: 30 * time.Second,
+				},
+				cli.BoolFlag{
+					Name:  "export",
+					Usage: "only print export ANDROID_SERIAL=<addr>, fa does not remember it, usage: eval $(fa tcpip --export)",
+				},
+			},
+			Action: actTcpip,
+		},
+		{
+			Name:      "reboot",
+			Usage:     "reboot device into normal, bootloader, recovery, sideload or fastboot mode",
+			UsageText: "fa reboot [normal|bootloader|recovery|sideload|fastboot] [--wait]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "wait, w",
+					Usage: "wait until sys.boot_completed=1, or device in recovery/sideload mode",
+				},
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "wait timeout, 0 means wait forever",
+					Value: 5 * time.Minute,
+				},
+			},
+			Action: actReboot,
+		},
+		{
+			Name:   "root",
+			Usage:  "restart adbd as root",
+			Action: actDeviceFunc((*adb.Device).Root),
+		},
+		{
+			Name:   "unroot",
+			Usage:  "restart adbd as non root",
+			Action: actDeviceFunc((*adb.Device).Unroot),
+		},
+		{
+			Name:   "remount",
+			Usage:  "remount system partitions as writable, adbd should be running as root",
+			Action: actDeviceFunc((*adb.Device).Remount),
+		},
+		{
+			Name:  "wlan",
+			Usage: "show wlan (ip, mac, signal), enable and disable it",
+			Subcommands: []cli.Command{
+				{
+					Name:  "status",
+					Usage: "show wlan ip, mac, ssid, rssi and link speed",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output in json format",
+						},
+					},
+					Action: actWlanStatus,
+				},
+				{
+					Name:   "on",
+					Usage:  "enable wlan",
+					Action: actWlanSwitch(true),
+				},
+				{
+					Name:   "off",
+					Usage:  "disable wlan",
+					Action: actWlanSwitch(false),
+				},
+				{
+					Name:      "connect",
+					Usage:     "connect to wlan network, require Android 11+",
+					UsageText: "fa wlan connect <ssid> [--password <password>]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "password, p",
+							Usage: "network password, empty for open network",
+						},
+						cli.StringFlag{
+							Name:  "security",
+							Usage: "one of open, owe, wpa2, wpa3, default wpa2 if password is set",
+						},
+						cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "wait timeout for connected and ip assigned",
+							Value: 30 * time.Second,
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "output wlan status in json format",
+						},
+					},
+					Action: actWlanConnect,
+				},
+			},
+		},
+		{
+			Name:      "unlock",
+			Usage:     "wake up screen and dismiss keyguard",
+			UsageText: "fa unlock [--pin 1234 | --pattern 1,2,3,6]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "pin",
+					Usage: "pin or password of lock screen",
+				},
+				cli.StringFlag{
+					Name:  "pattern",
+					Usage: "pattern points, numbered 1-9 from top-left, eg: 1,2,3,6",
+				},
+			},
+			Action: actUnlock,
+		},
 		{
 			Name:            "shell",
 			Usage:           "run shell command",
@@ -321,7 +802,7 @@ func main() {
 				if err != nil {
 					return err
 				}
-				device := DefaultAdbClient.DeviceWithSerial(serial)
+				device := newDevice(serial)
 
 				var cmd string
 				if len(ctx.Args()) != 0 {
@@ -345,7 +826,7 @@ func main() {
 		{
 			Name:      "install",
 			Usage:     "install apk",
-			UsageText: "fa install [ul] <apk-file | url>",
+			UsageText: "fa install [ul] [--all | --serials <serial> ...] <apk-file | url>",
 			// UseShortOptionHandling: true, // not supported in current urfav/cli
 			Flags: []cli.Flag{
 				cli.BoolFlag{
@@ -356,6 +837,35 @@ func main() {
 					Name:  "launch, l",
 					Usage: "launch after success installed",
 				},
+				cli.BoolFlag{
+					Name:  "wait, W",
+					Usage: "wait for launch complete and show launch timing, use with --launch",
+				},
+				cli.BoolFlag{
+					Name:  "all",
+					Usage: "install to all online devices concurrently, can not be used with --serials",
+				},
+				cli.StringSliceFlag{
+					Name:  "serials",
+					Usage: "install to given devices concurrently, can be set multiple times",
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "output install report in json format",
+				},
+				cli.StringFlag{
+					Name:  "sha256",
+					Usage: "verify apk with sha256 checksum",
+				},
+				cli.BoolFlag{
+					Name:  "no-cache",
+					Usage: "download url to current directory instead of user cache dir, eg: ~/.cache/fa",
+				},
+				cli.IntFlag{
+					Name:  "cache-size",
+					Usage: "max size(MB) of download cache, least recently used files are removed first",
+					Value: 1024,
+				},
 			},
 			Action: actInstall,
 		},
@@ -427,9 +937,7 @@ func main() {
 				if err != nil {
 					return err
 				}
-				client := NewAdbClient()
-				device := client.DeviceWithSerial(serial)
-				realSerial, err := device.SerialNo()
+				realSerial, err := newDevice(serial).Serial()
 				if err != nil {
 					return err
 				}
@@ -438,17 +946,71 @@ func main() {
 			},
 		},
 		{
-			Name:  "healthcheck",
-			Usage: "check device health status",
-			Action: func(ctx *cli.Context) error {
-				log.Println("check install")
-				err := runCommand(os.Args[0], "install", "-f", "https://github.com/appium/java-client/raw/master/src/test/java/io/appium/java_client/ApiDemos-debug.apk")
-				if err != nil {
-					return err
-				}
-				log.Println("OKAY")
-				return nil
+			Name:      "reset",
+			Usage:     "reset device state, clean up installed packages",
+			UsageText: "fa reset [--policy policy.yml] [--dry-run]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "policy, p",
+					Usage: "yaml file of packages to keep, files to clean and settings to put",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run, n",
+					Usage: "only print what would be done",
+				},
 			},
+			Action: actReset,
+		},
+		{
+			Name:      "healthcheck",
+			Usage:     "check device health status",
+			UsageText: "fa healthcheck [--check <name> ...] [--skip <name> ...] [--json]",
+			Description: "Checks: adb, battery, storage, screen, network, uptime, packages, install\n" +
+				"   Exit with non-zero code if any check failed",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "check",
+					Usage: "only run given checks, can be set multiple times",
+				},
+				cli.StringSliceFlag{
+					Name:  "skip",
+					Usage: "skip given checks, can be set multiple times",
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "output check results in json format",
+				},
+				cli.IntFlag{
+					Name:  "min-battery",
+					Usage: "fail if battery level(%) is lower",
+					Value: 20,
+				},
+				cli.Float64Flag{
+					Name:  "max-temperature",
+					Usage: "warn if battery temperature(°C) is higher",
+					Value: 45,
+				},
+				cli.IntFlag{
+					Name:  "min-storage",
+					Usage: "fail if free storage(MB) of /data is lower",
+					Value: 500,
+				},
+				cli.StringFlag{
+					Name:  "ping-host",
+					Usage: "host to ping for network check",
+					Value: "8.8.8.8",
+				},
+				cli.DurationFlag{
+					Name:  "max-uptime",
+					Usage: "warn if device is up longer, 0 to disable",
+					Value: 7 * 24 * time.Hour,
+				},
+				cli.DurationFlag{
+					Name:  "check-timeout",
+					Usage: "fail check not finished in duration, default 10s for adb, 2m for install and 30s for others",
+				},
+			},
+			Action: actHealthcheck,
 		},
 		{
 			Name:  "share",
@@ -469,8 +1031,7 @@ func main() {
 				if err != nil {
 					return err
 				}
-				client := adb.NewClient(fmt.Sprintf("%s:%d", defaultHost, defaultPort))
-				device := client.DeviceWithSerial(serial)
+				device := newDevice(serial)
 
 				if !ctx.Bool("tunnel") {
 					adbd := adb.NewADBDaemon(device)
@@ -505,44 +1066,58 @@ func main() {
 		{
 			Name:  "watch",
 			Usage: "show newest state when device state change",
+			Description: "Hooks run in background with env SERIAL, OLD_STATE, NEW_STATE, MODEL, SDK and TRANSPORT(usb, tcp or emulator)\n" +
+				"   States are device, offline, unauthorized and disconnected",
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "online-hook-cmd",
 					Usage: "run when device came online",
 				},
+				cli.StringFlag{
+					Name:  "offline-hook-cmd",
+					Usage: "run when device went offline or disconnected",
+				},
+				cli.StringFlag{
+					Name:  "state-hook-cmd",
+					Usage: "run when device state changed",
+				},
+				cli.DurationFlag{
+					Name:  "hook-timeout",
+					Usage: "kill hook after timeout, 0 means no timeout",
+					Value: 10 * time.Minute,
+				},
+				cli.IntFlag{
+					Name:  "hook-concurrency",
+					Usage: "max number of hooks running at the same time",
+					Value: 8,
+				},
+				cli.DurationFlag{
+					Name:  "debounce",
+					Usage: "merge state changes of the same device in duration before running hooks",
+					Value: 2 * time.Second,
+				},
+				cli.BoolFlag{
+					Name:  "json",
+					Usage: "output events as json lines with timestamp and device properties",
+				},
+				cli.StringFlag{
+					Name:  "webhook",
+					Usage: "POST every event in json to url, failed events are retried in order",
+				},
+				cli.StringFlag{
+					Name:  "webhook-queue-dir",
+					Usage: "directory to save events not delivered, default ~/.fa/webhook-queue",
+				},
 			},
-			Action: func(ctx *cli.Context) error {
-				client := NewAdbClient()
-				eventC, err := client.Watch()
-				if err != nil {
-					return err
-				}
-				onlineHook := ctx.String("online-hook-cmd")
-				for ev := range eventC {
-					fmt.Println(ev)
-					if ev.CameOnline() {
-						// log.Println("Online", ev)
-						var cmd *exec.Cmd
-						if runtime.GOOS == "windows" {
-							cmd = exec.Command("cmd", "/c", onlineHook)
-						} else {
-							cmd = exec.Command("bash", "-c", onlineHook)
-						}
-						cmd.Env = append(os.Environ(), "SERIAL="+ev.Serial)
-						cmd.Stdout = os.Stdout
-						cmd.Stderr = os.Stderr
-						cmd.Run()
-					}
-					// if ev.WentOffline {
-
-					// }
-				}
-				return nil
-			},
+			Action: actWatch,
 		},
 	}
 	err := app.Run(os.Args)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		if hint := adbErrorHint(err); hint != "" {
+			log.Println("hint:", hint)
+		}
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codeskyblue/fa/adb"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestAdbErrorHint(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		hint string
+	}{
+		{adb.ErrUnauthorized, "accept the RSA prompt"},
+		{&adb.ServerError{Message: "device offline"}, "adb reconnect offline"},
+		{fmt.Errorf("shell: %w", &adb.ServerError{Message: "device 'xyz' not found"}), "fa devices"},
+		{pkgerrors.Wrap(&adb.ServerError{Message: "more than one device/emulator"}, "resolve launch activity"), "-s <serial>"},
+		{adb.ErrServerNotAvailable, "adb start-server"},
+		{errors.New("exit status 1"), ""},
+	} {
+		hint := adbErrorHint(tc.err)
+		if tc.hint == "" {
+			if hint != "" {
+				t.Errorf("%v: expect no hint, got %q", tc.err, hint)
+			}
+			continue
+		}
+		if !strings.Contains(hint, tc.hint) {
+			t.Errorf("%v: expect hint contains %q, got %q", tc.err, tc.hint, hint)
+		}
+	}
+}
+
+func TestNoDeviceError(t *testing.T) {
+	unauthorized := Device{Serial: "a", Status: string(adb.StateUnauthorized)}
+	offline := Device{Serial: "b", Status: string(adb.StateOffline)}
+	for _, tc := range []struct {
+		devices []Device
+		target  error
+	}{
+		{nil, adb.ErrDeviceNotFound},
+		{[]Device{unauthorized}, adb.ErrUnauthorized},
+		{[]Device{offline, offline}, adb.ErrDeviceOffline},
+		{[]Device{unauthorized, offline}, adb.ErrDeviceNotFound},
+	} {
+		if err := noDeviceError(tc.devices); !errors.Is(err, tc.target) {
+			t.Errorf("%v: expect %v, got %v", tc.devices, tc.target, err)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/browser"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+const mirrorBoundary = "fa-mirror-frame"
+
+// screenMirror serve device screen as mjpeg stream, and forward input events to device
+type screenMirror struct {
+	device   *adb.Device
+	scale    float64
+	interval time.Duration
+	token    string // required in url query of every request
+
+	mu     sync.Mutex
+	width  int // size of the last screenshot
+	height int
+}
+
+// mirrorInput is posted by mirror page, x and y are ratios of screen size
+type mirrorInput struct {
+	Action   string  `json:"action"` // tap, swipe, keyevent, text
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	X2       float64 `json:"x2"`
+	Y2       float64 `json:"y2"`
+	Duration int     `json:"duration"` // milliseconds
+	Code     int     `json:"code"`
+	Text     string  `json:"text"`
+}
+
+// checkRequest reject requests without token, and cross-origin requests from other web pages
+func (m *screenMirror) checkRequest(w http.ResponseWriter, r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(m.token)) != 1 {
+		http.Error(w, "invalid token", http.StatusForbidden)
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+		http.Error(w, "cross-origin request is not allowed", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (m *screenMirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !m.checkRequest(w, r) {
+		return
+	}
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, mirrorHTML)
+	case "/stream.mjpeg":
+		m.serveStream(w, r)
+	case "/input":
+		m.serveInput(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *screenMirror) serveStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mirrorBoundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	buf := bytes.NewBuffer(nil)
+	for {
+		start := time.Now()
+		img, err := m.device.Screenshot(r.Context())
+		if err != nil {
+			if r.Context().Err() == nil {
+				log.Println("screenshot:", err)
+			}
+			return
+		}
+		m.mu.Lock()
+		m.width, m.height = img.Bounds().Dx(), img.Bounds().Dy()
+		m.mu.Unlock()
+		if m.scale != 1 {
+			img = resizeImage(img, m.scale)
+		}
+		buf.Reset()
+		if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 75}); err != nil {
+			log.Println("jpeg encode:", err)
+			return
+		}
+		fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", mirrorBoundary, buf.Len())
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return
+		}
+		fmt.Fprint(w, "\r\n")
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if elapsed := time.Since(start); elapsed < m.interval {
+			time.Sleep(m.interval - elapsed)
+		}
+	}
+}
+
+func (m *screenMirror) serveInput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// form posts of other pages can not set this content type without CORS preflight
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "content type should be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var in mirrorInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	width, height := float64(m.width), float64(m.height)
+	m.mu.Unlock()
+	pos := func(ratio, size float64) int {
+		return int(ratio * size)
+	}
+
+	var err error
+	switch in.Action {
+	case "tap":
+		err = m.device.Tap(pos(in.X, width), pos(in.Y, height))
+	case "swipe":
+		err = m.device.Swipe(pos(in.X, width), pos(in.Y, height),
+			pos(in.X2, width), pos(in.Y2, height), time.Duration(in.Duration)*time.Millisecond)
+	case "keyevent":
+		err = m.device.KeyEvent(strconv.Itoa(in.Code))
+	case "text":
+		err = m.device.Text(in.Text)
+	default:
+		http.Error(w, "unknown action: "+in.Action, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func actMirror(ctx *cli.Context) error {
+	scale := ctx.Float64("scale")
+	if scale <= 0 || scale > 1 {
+		return errors.New("scale should in range (0, 1]")
+	}
+	if ctx.Int("fps") <= 0 {
+		return errors.New("fps should be positive")
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	mirror := &screenMirror{
+		device:   device,
+		scale:    scale,
+		interval: time.Second / time.Duration(ctx.Int("fps")),
+		token:    hex.EncodeToString(token),
+	}
+	host := ctx.String("addr")
+	addr := net.JoinHostPort(host, strconv.Itoa(ctx.Int("port")))
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = GetLocalIP()
+	}
+	url := fmt.Sprintf("http://%s/?token=%s", net.JoinHostPort(host, strconv.Itoa(ctx.Int("port"))), mirror.token)
+	fmt.Println("Mirror on:", url)
+	if ctx.Bool("open") {
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			browser.OpenURL(url)
+		}()
+	}
+	return http.ListenAndServe(addr, mirror)
+}
+
+const mirrorHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>fa mirror</title>
+<style>
+body { margin: 0; background: #222; text-align: center; font-family: sans-serif; }
+#screen { max-height: 90vh; margin-top: 10px; cursor: pointer; user-select: none; -webkit-user-drag: none; }
+.keys { margin: 8px; }
+.keys button { margin: 0 4px; padding: 4px 12px; }
+</style>
+</head>
+<body>
+<div><img id="screen" draggable="false"></div>
+<div class="keys">
+  <button data-code="4">Back</button>
+  <button data-code="3">Home</button>
+  <button data-code="187">Recent</button>
+  <button data-code="26">Power</button>
+  <button data-code="24">Vol+</button>
+  <button data-code="25">Vol-</button>
+</div>
+<script>
+var token = new URLSearchParams(location.search).get("token");
+var img = document.getElementById("screen");
+img.src = "/stream.mjpeg?token=" + encodeURIComponent(token);
+var down = null;
+
+function send(input) {
+  fetch("/input?token=" + encodeURIComponent(token), {method: "POST",
+    headers: {"Content-Type": "application/json"}, body: JSON.stringify(input)});
+}
+
+function ratio(e) {
+  var rect = img.getBoundingClientRect();
+  return {x: (e.clientX - rect.left) / rect.width, y: (e.clientY - rect.top) / rect.height, t: Date.now()};
+}
+
+img.addEventListener("mousedown", function(e) { down = ratio(e); });
+img.addEventListener("mouseup", function(e) {
+  if (!down) return;
+  var up = ratio(e);
+  var moved = Math.abs(up.x - down.x) > 0.01 || Math.abs(up.y - down.y) > 0.01;
+  if (moved) {
+    send({action: "swipe", x: down.x, y: down.y, x2: up.x, y2: up.y, duration: Math.max(up.t - down.t, 100)});
+  } else if (up.t - down.t > 500) {
+    send({action: "swipe", x: down.x, y: down.y, x2: up.x, y2: up.y, duration: up.t - down.t});
+  } else {
+    send({action: "tap", x: up.x, y: up.y});
+  }
+  down = null;
+});
+
+document.querySelectorAll(".keys button").forEach(function(btn) {
+  btn.addEventListener("click", function() {
+    send({action: "keyevent", code: parseInt(btn.dataset.code)});
+  });
+});
+
+var keycodes = {Enter: 66, Backspace: 67, Delete: 112, Escape: 4, Tab: 61, Home: 3,
+  ArrowUp: 19, ArrowDown: 20, ArrowLeft: 21, ArrowRight: 22};
+document.addEventListener("keydown", function(e) {
+  if (e.ctrlKey || e.metaKey || e.altKey) return;
+  if (keycodes[e.key]) {
+    send({action: "keyevent", code: keycodes[e.key]});
+  } else if (e.key.length === 1) {
+    send({action: "text", text: e.key});
+  } else {
+    return;
+  }
+  e.preventDefault();
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScreenMirrorCheckRequest(t *testing.T) {
+	m := &screenMirror{token: "secret"}
+	for _, tc := range []struct {
+		url         string
+		origin      string
+		contentType string
+		status      int
+	}{
+		{"/input", "", "application/json", http.StatusForbidden},
+		{"/input?token=wrong", "", "application/json", http.StatusForbidden},
+		{"/stream.mjpeg", "", "", http.StatusForbidden},
+		{"/input?token=secret", "http://evil.example.com", "application/json", http.StatusForbidden},
+		{"/input?token=secret", "http://127.0.0.1:6180", "text/plain", http.StatusUnsupportedMediaType},
+		{"/input?token=secret", "http://127.0.0.1:6180", "application/json", http.StatusBadRequest}, // body is not json
+		{"/input?token=secret", "", "application/json; charset=utf-8", http.StatusBadRequest},
+		{"/?token=secret", "", "", http.StatusOK},
+	} {
+		method := http.MethodPost
+		if !strings.HasPrefix(tc.url, "/input") {
+			method = http.MethodGet
+		}
+		req := httptest.NewRequest(method, "http://127.0.0.1:6180"+tc.url, strings.NewReader("not json"))
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s %s origin=%q content-type=%q: expect %d, got %d", method, tc.url, tc.origin, tc.contentType, tc.status, rec.Code)
+		}
+	}
+}
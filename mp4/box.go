@@ -0,0 +1,176 @@
+// Package mp4 implements just enough ISO base media file format to
+// join video segments recorded by Android screenrecord.
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// box is an ISO BMFF box, container boxes have children instead of data
+type box struct {
+	typ      string
+	data     []byte
+	children []*box
+}
+
+var containerTypes = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+	"edts": true,
+	"dinf": true,
+}
+
+// topBox is the position of a top level box in file
+type topBox struct {
+	typ        string
+	offset     int64 // box start
+	headerSize int64
+	size       int64 // include header
+}
+
+// scanBoxes list top level boxes of file
+func scanBoxes(r io.ReaderAt, fileSize int64) (boxes []topBox, err error) {
+	var offset int64
+	for offset < fileSize {
+		header := make([]byte, 16)
+		if _, err = r.ReadAt(header[:8], offset); err != nil {
+			return
+		}
+		b := topBox{
+			typ:        string(header[4:8]),
+			offset:     offset,
+			headerSize: 8,
+			size:       int64(binary.BigEndian.Uint32(header[:4])),
+		}
+		switch b.size {
+		case 0: // box extends to end of file
+			b.size = fileSize - offset
+		case 1: // 64-bit largesize
+			if _, err = r.ReadAt(header[8:16], offset+8); err != nil {
+				return
+			}
+			b.size = int64(binary.BigEndian.Uint64(header[8:16]))
+			b.headerSize = 16
+		}
+		if b.size < b.headerSize || offset+b.size > fileSize {
+			return nil, fmt.Errorf("mp4: invalid box %q at %d", b.typ, offset)
+		}
+		boxes = append(boxes, b)
+		offset += b.size
+	}
+	return
+}
+
+// parseBoxes parse payload into boxes, container boxes are parsed recursively
+func parseBoxes(data []byte) (boxes []*box, err error) {
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("mp4: truncated box header")
+		}
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		typ := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("mp4: truncated box header")
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("mp4: invalid box %q", typ)
+		}
+		b := &box{typ: typ}
+		payload := data[headerSize:size]
+		if containerTypes[typ] {
+			if b.children, err = parseBoxes(payload); err != nil {
+				return
+			}
+		} else {
+			b.data = payload
+		}
+		boxes = append(boxes, b)
+		data = data[size:]
+	}
+	return
+}
+
+// child returns the first child with type
+func (b *box) child(typ string) *box {
+	for _, c := range b.children {
+		if c.typ == typ {
+			return c
+		}
+	}
+	return nil
+}
+
+// path returns descendant box, eg: b.path("mdia", "minf", "stbl")
+func (b *box) path(types ...string) *box {
+	for _, typ := range types {
+		if b = b.child(typ); b == nil {
+			return nil
+		}
+	}
+	return b
+}
+
+func (b *box) removeChild(typ string) {
+	children := b.children[:0]
+	for _, c := range b.children {
+		if c.typ != typ {
+			children = append(children, c)
+		}
+	}
+	b.children = children
+}
+
+// setChild replace child with same type, or append if not exists
+func (b *box) setChild(c *box) {
+	for i, old := range b.children {
+		if old.typ == c.typ {
+			b.children[i] = c
+			return
+		}
+	}
+	b.children = append(b.children, c)
+}
+
+func (b *box) encode(buf *bytes.Buffer) {
+	start := buf.Len()
+	buf.Write([]byte{0, 0, 0, 0})
+	buf.WriteString(b.typ)
+	if b.children != nil {
+		for _, c := range b.children {
+			c.encode(buf)
+		}
+	} else {
+		buf.Write(b.data)
+	}
+	binary.BigEndian.PutUint32(buf.Bytes()[start:], uint32(buf.Len()-start))
+}
+
+func (b *box) bytes() []byte {
+	buf := bytes.NewBuffer(nil)
+	b.encode(buf)
+	return buf.Bytes()
+}
+
+// fullBox returns payload of full box with version and flags
+func fullBox(typ string, version uint8, flags uint32, fields ...interface{}) *box {
+	buf := bytes.NewBuffer(nil)
+	binary.Write(buf, binary.BigEndian, uint32(version)<<24|flags&0xffffff)
+	for _, f := range fields {
+		binary.Write(buf, binary.BigEndian, f)
+	}
+	return &box{typ: typ, data: buf.Bytes()}
+}
@@ -0,0 +1,478 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+type sample struct {
+	offset    int64
+	size      uint32
+	delta     uint32 // duration in track timescale
+	ctsOffset int32
+	sync      bool
+}
+
+// segment is the video track of a mp4 file
+type segment struct {
+	file      *os.File
+	ftyp      []byte
+	moov      *box
+	trak      *box
+	timescale uint32
+	samples   []sample
+	hasStss   bool
+	hasCtts   bool
+}
+
+func be32(data []byte, offset int) uint32 {
+	if offset+4 > len(data) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(data[offset:])
+}
+
+// table returns entry count and entries of stts, stsz etc. which have 4 bytes version and flags
+func table(b *box, headerSize, entrySize int) (n int, entries []byte, err error) {
+	if b == nil || len(b.data) < headerSize {
+		return 0, nil, fmt.Errorf("mp4: missing or invalid table")
+	}
+	n = int(be32(b.data, headerSize-4))
+	entries = b.data[headerSize:]
+	if len(entries) < n*entrySize {
+		return 0, nil, fmt.Errorf("mp4: truncated %s", b.typ)
+	}
+	return n, entries, nil
+}
+
+func openSegment(path string) (seg *segment, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+		}
+	}()
+	finfo, err := f.Stat()
+	if err != nil {
+		return
+	}
+	tops, err := scanBoxes(f, finfo.Size())
+	if err != nil {
+		return
+	}
+	seg = &segment{file: f}
+	for _, tb := range tops {
+		switch tb.typ {
+		case "ftyp":
+			seg.ftyp = make([]byte, tb.size)
+			_, err = f.ReadAt(seg.ftyp, tb.offset)
+		case "moov":
+			data := make([]byte, tb.size-tb.headerSize)
+			if _, err = f.ReadAt(data, tb.offset+tb.headerSize); err != nil {
+				return
+			}
+			seg.moov = &box{typ: "moov"}
+			seg.moov.children, err = parseBoxes(data)
+		}
+		if err != nil {
+			return
+		}
+	}
+	if seg.moov == nil {
+		return nil, errors.New("mp4: moov not found in " + path)
+	}
+	for _, trak := range seg.moov.children {
+		hdlr := trak.path("mdia", "hdlr")
+		if trak.typ == "trak" && hdlr != nil && len(hdlr.data) >= 12 && string(hdlr.data[8:12]) == "vide" {
+			seg.trak = trak
+			break
+		}
+	}
+	if seg.trak == nil {
+		return nil, errors.New("mp4: video track not found in " + path)
+	}
+	if err = seg.parseSamples(); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+func (seg *segment) parseSamples() error {
+	mdhd := seg.trak.path("mdia", "mdhd")
+	if mdhd == nil || len(mdhd.data) < 24 {
+		return errors.New("mp4: invalid mdhd")
+	}
+	if mdhd.data[0] == 1 {
+		seg.timescale = be32(mdhd.data, 20)
+	} else {
+		seg.timescale = be32(mdhd.data, 12)
+	}
+	stbl := seg.trak.path("mdia", "minf", "stbl")
+	if stbl == nil {
+		return errors.New("mp4: stbl not found")
+	}
+
+	// sample sizes
+	stsz := stbl.child("stsz")
+	if stsz == nil || len(stsz.data) < 12 {
+		return errors.New("mp4: invalid stsz")
+	}
+	sampleSize := be32(stsz.data, 4)
+	count := int(be32(stsz.data, 8))
+	if sampleSize == 0 && (len(stsz.data)-12)/4 < count {
+		return fmt.Errorf("mp4: truncated stsz, %d samples expected", count)
+	}
+	seg.samples = make([]sample, count)
+	for i := range seg.samples {
+		if sampleSize != 0 {
+			seg.samples[i].size = sampleSize
+		} else {
+			seg.samples[i].size = be32(stsz.data, 12+i*4)
+		}
+	}
+
+	// sample offsets
+	var chunkOffsets []int64
+	if co64 := stbl.child("co64"); co64 != nil {
+		n, entries, err := table(co64, 8, 8)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			chunkOffsets = append(chunkOffsets, int64(binary.BigEndian.Uint64(entries[i*8:])))
+		}
+	} else {
+		n, entries, err := table(stbl.child("stco"), 8, 4)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			chunkOffsets = append(chunkOffsets, int64(be32(entries, i*4)))
+		}
+	}
+	nstsc, stsc, err := table(stbl.child("stsc"), 8, 12)
+	if err != nil {
+		return err
+	}
+	index := 0
+	for chunk := range chunkOffsets {
+		var samplesPerChunk uint32
+		for i := 0; i < nstsc && int(be32(stsc, i*12)) <= chunk+1; i++ {
+			samplesPerChunk = be32(stsc, i*12+4)
+		}
+		offset := chunkOffsets[chunk]
+		for j := uint32(0); j < samplesPerChunk && index < count; j++ {
+			seg.samples[index].offset = offset
+			offset += int64(seg.samples[index].size)
+			index++
+		}
+	}
+	if index != count {
+		return fmt.Errorf("mp4: %d samples in chunks, expect %d", index, count)
+	}
+
+	// sample durations
+	nstts, stts, err := table(stbl.child("stts"), 8, 8)
+	if err != nil {
+		return err
+	}
+	index = 0
+	for i := 0; i < nstts; i++ {
+		n, delta := be32(stts, i*8), be32(stts, i*8+4)
+		for j := uint32(0); j < n && index < count; j++ {
+			seg.samples[index].delta = delta
+			index++
+		}
+	}
+
+	// composition offsets
+	if ctts := stbl.child("ctts"); ctts != nil {
+		n, entries, err := table(ctts, 8, 8)
+		if err != nil {
+			return err
+		}
+		seg.hasCtts = true
+		index = 0
+		for i := 0; i < n; i++ {
+			cnt, offset := be32(entries, i*8), int32(be32(entries, i*8+4))
+			for j := uint32(0); j < cnt && index < count; j++ {
+				seg.samples[index].ctsOffset = offset
+				index++
+			}
+		}
+	}
+
+	// sync samples, all samples are sync samples without stss
+	if stss := stbl.child("stss"); stss != nil {
+		n, entries, err := table(stss, 8, 4)
+		if err != nil {
+			return err
+		}
+		seg.hasStss = true
+		for i := 0; i < n; i++ {
+			if num := int(be32(entries, i*4)); num >= 1 && num <= count {
+				seg.samples[num-1].sync = true
+			}
+		}
+	} else {
+		for i := range seg.samples {
+			seg.samples[i].sync = true
+		}
+	}
+	return nil
+}
+
+// setDuration patch duration of mvhd, tkhd or mdhd
+func setDuration(b *box, offsetV0, offsetV1 int, duration uint64) {
+	data := append([]byte(nil), b.data...)
+	if data[0] == 1 && len(data) >= offsetV1+8 {
+		binary.BigEndian.PutUint64(data[offsetV1:], duration)
+	} else if len(data) >= offsetV0+4 {
+		binary.BigEndian.PutUint32(data[offsetV0:], uint32(duration))
+	}
+	b.data = data
+}
+
+// ConcatFiles join mp4 files into one file, see Concat
+func ConcatFiles(output string, inputs ...string) (err error) {
+	f, err := os.Create(output)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(output)
+		}
+	}()
+	return Concat(f, inputs...)
+}
+
+// SampleDescriptionError is returned by Concat when sample description (codec config, resolution)
+// of input differs from the first input, eg: screen rotated between segments.
+// Inputs before Index can be joined together
+type SampleDescriptionError struct {
+	Index int
+	Path  string
+}
+
+func (e *SampleDescriptionError) Error() string {
+	return fmt.Sprintf("mp4: sample description of %s differs from the first input", e.Path)
+}
+
+// Concat join video track of mp4 files, like those recorded by screenrecord.
+// Sample description of the first file is used for all samples, *SampleDescriptionError
+// is returned if inputs are encoded with different settings.
+// Output moov is placed before mdat, which make it playable while downloading.
+func Concat(w io.Writer, inputs ...string) error {
+	if len(inputs) == 0 {
+		return errors.New("mp4: no input files")
+	}
+	segs := make([]*segment, 0, len(inputs))
+	defer func() {
+		for _, seg := range segs {
+			seg.file.Close()
+		}
+	}()
+	for _, input := range inputs {
+		seg, err := openSegment(input)
+		if err != nil {
+			return err
+		}
+		segs = append(segs, seg)
+	}
+
+	first := segs[0]
+	firstStsd := first.trak.path("mdia", "minf", "stbl", "stsd")
+	if firstStsd == nil {
+		return errors.New("mp4: stsd not found in " + inputs[0])
+	}
+	for i, seg := range segs[1:] {
+		stsd := seg.trak.path("mdia", "minf", "stbl", "stsd")
+		if stsd == nil || !bytes.Equal(stsd.bytes(), firstStsd.bytes()) {
+			return &SampleDescriptionError{Index: i + 1, Path: inputs[i+1]}
+		}
+	}
+	var (
+		samples  []sample
+		hasStss  bool
+		hasCtts  bool
+		dataSize int64
+		duration uint64
+	)
+	for _, seg := range segs {
+		hasStss = hasStss || seg.hasStss
+		hasCtts = hasCtts || seg.hasCtts
+		for _, s := range seg.samples {
+			if seg.timescale != first.timescale && seg.timescale != 0 {
+				s.delta = uint32(uint64(s.delta) * uint64(first.timescale) / uint64(seg.timescale))
+				s.ctsOffset = int32(int64(s.ctsOffset) * int64(first.timescale) / int64(seg.timescale))
+			}
+			samples = append(samples, s)
+			dataSize += int64(s.size)
+			duration += uint64(s.delta)
+		}
+	}
+
+	// build moov with only the video track of the first file
+	moov := &box{typ: "moov"}
+	for _, c := range first.moov.children {
+		if c.typ == "trak" && c != first.trak {
+			continue
+		}
+		moov.children = append(moov.children, c)
+	}
+	first.trak.removeChild("edts")
+	stbl := first.trak.path("mdia", "minf", "stbl")
+	stbl.removeChild("sdtp")
+	stbl.removeChild("stco")
+	stbl.removeChild("co64")
+	stbl.removeChild("stss")
+	stbl.removeChild("ctts")
+	stbl.setChild(buildStts(samples))
+	stbl.setChild(buildStsz(samples))
+	stbl.setChild(fullBox("stsc", 0, 0, uint32(1), uint32(1), uint32(1), uint32(1))) // one sample per chunk
+	if hasStss {
+		stbl.setChild(buildStss(samples))
+	}
+	if hasCtts {
+		stbl.setChild(buildCtts(samples))
+	}
+
+	mvhd := moov.child("mvhd")
+	if mvhd == nil || len(mvhd.data) < 20 {
+		return errors.New("mp4: invalid mvhd")
+	}
+	movieTimescale := be32(mvhd.data, 12)
+	if mvhd.data[0] == 1 {
+		movieTimescale = be32(mvhd.data, 20)
+	}
+	movieDuration := duration
+	if first.timescale != 0 {
+		movieDuration = duration * uint64(movieTimescale) / uint64(first.timescale)
+	}
+	setDuration(mvhd, 16, 24, movieDuration)
+	setDuration(first.trak.child("tkhd"), 20, 28, movieDuration)
+	setDuration(first.trak.path("mdia", "mdhd"), 16, 24, duration)
+
+	// chunk offsets depend on moov size, use placeholder to calculate size first
+	mdatHeaderSize := int64(8)
+	if dataSize+8 > 0xffffffff {
+		mdatHeaderSize = 16
+	}
+	stbl.setChild(buildChunkOffsets(samples, 0, false))
+	use64 := int64(len(first.ftyp))+int64(len(moov.bytes()))+int64(len(samples))*4+mdatHeaderSize+dataSize > 0xffffffff
+	stbl.setChild(buildChunkOffsets(samples, 0, use64))
+	offset := int64(len(first.ftyp)) + int64(len(moov.bytes())) + mdatHeaderSize
+	stbl.setChild(buildChunkOffsets(samples, offset, use64))
+
+	if _, err := w.Write(first.ftyp); err != nil {
+		return err
+	}
+	if _, err := w.Write(moov.bytes()); err != nil {
+		return err
+	}
+	mdatHeader := bytes.NewBuffer(nil)
+	if mdatHeaderSize == 16 {
+		binary.Write(mdatHeader, binary.BigEndian, uint32(1))
+		mdatHeader.WriteString("mdat")
+		binary.Write(mdatHeader, binary.BigEndian, uint64(dataSize+16))
+	} else {
+		binary.Write(mdatHeader, binary.BigEndian, uint32(dataSize+8))
+		mdatHeader.WriteString("mdat")
+	}
+	if _, err := w.Write(mdatHeader.Bytes()); err != nil {
+		return err
+	}
+	for _, seg := range segs {
+		if err := copySamples(w, seg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copySamples write sample data in order, adjacent samples are copied together
+func copySamples(w io.Writer, seg *segment) error {
+	for i := 0; i < len(seg.samples); {
+		start, size := seg.samples[i].offset, int64(seg.samples[i].size)
+		i++
+		for i < len(seg.samples) && seg.samples[i].offset == start+size {
+			size += int64(seg.samples[i].size)
+			i++
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(seg.file, start, size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildStts(samples []sample) *box {
+	var entries []uint32
+	for i, s := range samples {
+		if i > 0 && entries[len(entries)-1] == s.delta {
+			entries[len(entries)-2]++
+			continue
+		}
+		entries = append(entries, 1, s.delta)
+	}
+	return fullBox("stts", 0, 0, uint32(len(entries)/2), entries)
+}
+
+func buildCtts(samples []sample) *box {
+	var entries []int32
+	for i, s := range samples {
+		if i > 0 && entries[len(entries)-1] == s.ctsOffset {
+			entries[len(entries)-2]++
+			continue
+		}
+		entries = append(entries, 1, s.ctsOffset)
+	}
+	// version 1 allows negative offsets
+	return fullBox("ctts", 1, 0, uint32(len(entries)/2), entries)
+}
+
+func buildStsz(samples []sample) *box {
+	sizes := make([]uint32, len(samples))
+	for i, s := range samples {
+		sizes[i] = s.size
+	}
+	return fullBox("stsz", 0, 0, uint32(0), uint32(len(sizes)), sizes)
+}
+
+func buildStss(samples []sample) *box {
+	var nums []uint32
+	for i, s := range samples {
+		if s.sync {
+			nums = append(nums, uint32(i+1))
+		}
+	}
+	return fullBox("stss", 0, 0, uint32(len(nums)), nums)
+}
+
+func buildChunkOffsets(samples []sample, offset int64, use64 bool) *box {
+	if use64 {
+		offsets := make([]uint64, len(samples))
+		for i, s := range samples {
+			offsets[i] = uint64(offset)
+			offset += int64(s.size)
+		}
+		return fullBox("co64", 0, 0, uint32(len(offsets)), offsets)
+	}
+	offsets := make([]uint32, len(samples))
+	for i, s := range samples {
+		offsets[i] = uint32(offset)
+		offset += int64(s.size)
+	}
+	return fullBox("stco", 0, 0, uint32(len(offsets)), offsets)
+}
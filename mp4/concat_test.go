@@ -0,0 +1,140 @@
+package mp4
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestMp4 create mp4 with one video track, samples are stored in one chunk
+func writeTestMp4(t *testing.T, path string, timescale uint32, samples [][]byte) {
+	writeTestMp4Stsd(t, path, timescale, fullBox("stsd", 0, 0, uint32(0)), samples)
+}
+
+func writeTestMp4Stsd(t *testing.T, path string, timescale uint32, stsd *box, samples [][]byte) {
+	ftyp := &box{typ: "ftyp", data: []byte("mp42\x00\x00\x00\x00isommp42")}
+	sizes := make([]uint32, len(samples))
+	for i, s := range samples {
+		sizes[i] = uint32(len(s))
+	}
+	stbl := &box{typ: "stbl", children: []*box{
+		stsd,
+		fullBox("stts", 0, 0, uint32(1), uint32(len(samples)), uint32(timescale/30)),
+		fullBox("stss", 0, 0, uint32(1), uint32(1)),
+		fullBox("stsz", 0, 0, uint32(0), uint32(len(samples)), sizes),
+		fullBox("stsc", 0, 0, uint32(1), uint32(1), uint32(len(samples)), uint32(1)),
+		fullBox("stco", 0, 0, uint32(1), uint32(0)),
+	}}
+	moov := &box{typ: "moov", children: []*box{
+		fullBox("mvhd", 0, 0, uint32(0), uint32(0), uint32(1000), uint32(0)),
+		{typ: "trak", children: []*box{
+			fullBox("tkhd", 0, 3, uint32(0), uint32(0), uint32(1), uint32(0), uint32(0)),
+			{typ: "edts", children: []*box{fullBox("elst", 0, 0, uint32(0))}},
+			{typ: "mdia", children: []*box{
+				fullBox("mdhd", 0, 0, uint32(0), uint32(0), timescale, uint32(0), uint32(0)),
+				fullBox("hdlr", 0, 0, uint32(0), []byte("vide")),
+				{typ: "minf", children: []*box{stbl}},
+			}},
+		}},
+	}}
+	// mdat is placed before moov like screenrecord does
+	offset := uint32(len(ftyp.bytes()) + 8)
+	stbl.setChild(fullBox("stco", 0, 0, uint32(1), offset))
+	mdat := &box{typ: "mdat", data: bytes.Join(samples, nil)}
+
+	buf := bytes.NewBuffer(nil)
+	buf.Write(ftyp.bytes())
+	buf.Write(mdat.bytes())
+	buf.Write(moov.bytes())
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fa-mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	seg1 := filepath.Join(dir, "1.mp4")
+	seg2 := filepath.Join(dir, "2.mp4")
+	output := filepath.Join(dir, "out.mp4")
+	writeTestMp4(t, seg1, 90000, [][]byte{[]byte("aaaa"), []byte("bb")})
+	writeTestMp4(t, seg2, 9000, [][]byte{[]byte("ccc")})
+
+	if !assert.NoError(t, ConcatFiles(output, seg1, seg2)) {
+		return
+	}
+	seg, err := openSegment(output)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer seg.file.Close()
+
+	assert.Equal(t, uint32(90000), seg.timescale)
+	assert.Len(t, seg.samples, 3)
+	var data []byte
+	for _, s := range seg.samples {
+		buf := make([]byte, s.size)
+		seg.file.ReadAt(buf, s.offset)
+		data = append(data, buf...)
+		assert.Equal(t, uint32(3000), s.delta)
+	}
+	assert.Equal(t, "aaaabbccc", string(data))
+	assert.True(t, seg.samples[0].sync)
+	assert.False(t, seg.samples[1].sync)
+	assert.True(t, seg.samples[2].sync)
+	assert.Nil(t, seg.trak.child("edts"))
+
+	mdhd := seg.trak.path("mdia", "mdhd")
+	assert.Equal(t, uint32(9000), be32(mdhd.data, 16))
+	mvhd := seg.moov.child("mvhd")
+	assert.Equal(t, uint32(100), be32(mvhd.data, 16))
+}
+
+func TestConcatSampleDescriptionChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fa-mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	seg1 := filepath.Join(dir, "1.mp4")
+	seg2 := filepath.Join(dir, "2.mp4")
+	seg3 := filepath.Join(dir, "3.mp4")
+	writeTestMp4(t, seg1, 90000, [][]byte{[]byte("aaaa")})
+	writeTestMp4(t, seg2, 90000, [][]byte{[]byte("bb")})
+	// rotated, avc1 entry with another resolution
+	writeTestMp4Stsd(t, seg3, 90000, fullBox("stsd", 0, 0, uint32(1), []byte("avc1 1080x1920")), [][]byte{[]byte("ccc")})
+
+	err = ConcatFiles(filepath.Join(dir, "out.mp4"), seg1, seg2, seg3)
+	if serr, ok := err.(*SampleDescriptionError); assert.True(t, ok, "%v", err) {
+		assert.Equal(t, 2, serr.Index)
+		assert.Equal(t, seg3, serr.Path)
+	}
+	_, err = os.Stat(filepath.Join(dir, "out.mp4"))
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, ConcatFiles(filepath.Join(dir, "out.mp4"), seg1, seg2))
+}
+
+func TestParseSamplesTruncatedStsz(t *testing.T) {
+	trak := &box{typ: "trak", children: []*box{
+		{typ: "mdia", children: []*box{
+			fullBox("mdhd", 0, 0, uint32(0), uint32(0), uint32(90000), uint32(0), uint32(0)),
+			{typ: "minf", children: []*box{
+				{typ: "stbl", children: []*box{
+					fullBox("stsz", 0, 0, uint32(0), uint32(1000), uint32(4)), // 1000 samples, 1 size
+				}},
+			}},
+		}},
+	}}
+	err := (&segment{trak: trak}).parseSamples()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "truncated stsz")
+	}
+}
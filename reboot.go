@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+func actReboot(ctx *cli.Context) error {
+	mode := adb.RebootMode(ctx.Args().First())
+	if mode == "normal" {
+		mode = adb.RebootNormal
+	}
+	wait := ctx.Bool("wait")
+	if wait && (mode == adb.RebootBootloader || mode == adb.RebootFastboot) {
+		return fmt.Errorf("--wait is not supported for %s, device is not visible to adb", mode)
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	if err := device.Reboot(mode); err != nil {
+		return err
+	}
+	if !wait {
+		return nil
+	}
+
+	waitCtx := context.Background()
+	if timeout := ctx.Duration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(waitCtx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	switch mode {
+	case adb.RebootRecovery:
+		err = device.WaitFor(waitCtx, adb.StateRecovery)
+	case adb.RebootSideload:
+		err = device.WaitFor(waitCtx, adb.StateSideload)
+	default:
+		err = device.WaitRebootCompleted(waitCtx)
+	}
+	if err == context.DeadlineExceeded {
+		return errors.New("wait for device reboot timeout")
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Device is ready in %v", time.Since(start).Round(time.Second))
+	return nil
+}
+
+func actDeviceFunc(fn func(device *adb.Device) error) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		device, err := chooseDevice()
+		if err != nil {
+			return err
+		}
+		return fn(device)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// resetPolicy describes the known state of device.
+// Fields in policy file replace the defaults, except settings which are merged into defaults,
+// a setting with empty value in policy file is not changed
+type resetPolicy struct {
+	Uninstall      bool                         `yaml:"uninstall"`
+	KeepPackages   []string                     `yaml:"keep_packages"` // glob patterns, eg: io.appium.*
+	Clean          []string                     `yaml:"clean"`         // remove files, glob *?[] is expanded on device
+	Settings       map[string]map[string]string `yaml:"settings"`      // namespace -> key -> value
+	RemoveForwards bool                         `yaml:"remove_forwards"`
+}
+
+var defaultResetPolicy = resetPolicy{
+	Uninstall:    true,
+	KeepPackages: []string{},
+	// test directories on /sdcard differ between projects and shared storage may keep user data,
+	// so only files left by fa are cleaned by default, add test directories in policy file
+	Clean: []string{"/data/local/tmp/*", "/sdcard/fa-*"},
+	Settings: map[string]map[string]string{
+		"global": {
+			"window_animation_scale":     "1.0",
+			"transition_animation_scale": "1.0",
+			"animator_duration_scale":    "1.0",
+			"stay_on_while_plugged_in":   "0",
+		},
+		"system": {
+			"screen_off_timeout":     "60000",
+			"screen_brightness_mode": "0",
+			"screen_brightness":      "128",
+			"accelerometer_rotation": "1",
+		},
+	},
+	RemoveForwards: true,
+}
+
+// only files under these directories can be cleaned
+var resetCleanRoots = []string{"/sdcard/", "/storage/emulated/", "/data/local/tmp/"}
+
+func loadResetPolicy(filename string) (policy resetPolicy, err error) {
+	policy = defaultResetPolicy
+	if filename == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	policy.Settings = nil
+	if err = yaml.UnmarshalStrict(data, &policy); err != nil {
+		return policy, errors.Wrap(err, "parse "+filename)
+	}
+	// settings from policy file are merged into a copy of the defaults, empty value removes the default
+	settings := make(map[string]map[string]string)
+	for _, source := range []map[string]map[string]string{defaultResetPolicy.Settings, policy.Settings} {
+		for namespace, values := range source {
+			if settings[namespace] == nil {
+				settings[namespace] = make(map[string]string)
+			}
+			for key, value := range values {
+				if value == "" {
+					delete(settings[namespace], key)
+				} else {
+					settings[namespace][key] = value
+				}
+			}
+		}
+	}
+	policy.Settings = settings
+	for _, p := range policy.Clean {
+		if err = checkCleanPath(p); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// cleanPathRE allows glob characters *?[] but no whitespace or other shell metacharacters
+var cleanPathRE = regexp.MustCompile(`^[A-Za-z0-9/._+,=@:%*?\[\]-]+$`)
+
+// checkCleanPath check path in policy, it is passed to device shell to expand glob
+func checkCleanPath(p string) error {
+	if !cleanPathRE.MatchString(p) {
+		return fmt.Errorf("clean path %q should not contains whitespace or shell characters except *?[]", p)
+	}
+	return checkCleanTarget(p)
+}
+
+// checkCleanTarget check path is under one of resetCleanRoots, glob results are checked again before removed
+func checkCleanTarget(p string) error {
+	if strings.Contains(p, "..") || path.Clean(p) != p {
+		return fmt.Errorf("clean path %q should be clean and not contains ..", p)
+	}
+	for _, root := range resetCleanRoots {
+		if strings.HasPrefix(p, root) && len(p) > len(root) {
+			return nil
+		}
+	}
+	return fmt.Errorf("clean path %q should under one of %s", p, strings.Join(resetCleanRoots, ", "))
+}
+
+// cleanPath remove p on device, glob is expanded on device and every result is checked before removed
+func cleanPath(device *adb.Device, p string) error {
+	targets := []string{p}
+	if strings.ContainsAny(p, "*?[") {
+		// p only contains safe characters, so it is not quoted to expand glob
+		output, err := device.RunCommand("sh", "-c", `for f in `+p+`; do [ -e "$f" -o -L "$f" ] && echo "$f"; done`)
+		if err != nil {
+			return err
+		}
+		targets = nil
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				targets = append(targets, line)
+			}
+		}
+	}
+	for _, target := range targets {
+		if err := checkCleanTarget(target); err != nil {
+			return err
+		}
+		output, err := device.RunCommand("rm", "-rf", target)
+		if err == nil && strings.TrimSpace(output) != "" {
+			err = errors.New(strings.TrimSpace(output))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepPackage returns true if name matches any pattern
+func keepPackage(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resetStep is one action of reset, printed instead of run with --dry-run
+type resetStep struct {
+	Desc string
+	Run  func() error
+}
+
+func resetSteps(device *adb.Device, policy resetPolicy) (steps []resetStep, err error) {
+	if policy.Uninstall {
+		packages, err := device.ListPackages("-3")
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(packages)
+		for _, name := range packages {
+			if keepPackage(policy.KeepPackages, name) {
+				continue
+			}
+			name := name
+			steps = append(steps, resetStep{"uninstall " + name, func() error {
+				return device.AppUninstall(name, false)
+			}})
+		}
+	}
+	for _, p := range policy.Clean {
+		p := p
+		steps = append(steps, resetStep{"rm -rf " + p, func() error {
+			return cleanPath(device, p)
+		}})
+	}
+	namespaces := make([]string, 0, len(policy.Settings))
+	for namespace := range policy.Settings {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		keys := make([]string, 0, len(policy.Settings[namespace]))
+		for key := range policy.Settings[namespace] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			args := []string{"settings", "put", namespace, key, policy.Settings[namespace][key]}
+			steps = append(steps, resetStep{strings.Join(args, " "), func() error {
+				output, err := device.RunCommand(args...)
+				if err == nil && strings.TrimSpace(output) != "" {
+					err = errors.New(strings.TrimSpace(output))
+				}
+				return err
+			}})
+		}
+	}
+	if policy.RemoveForwards {
+		steps = append(steps, resetStep{"remove all forwards", device.RemoveAllForwards})
+		steps = append(steps, resetStep{"remove all reverses", device.RemoveAllReverses})
+	}
+	return steps, nil
+}
+
+func actReset(ctx *cli.Context) error {
+	policy, err := loadResetPolicy(ctx.String("policy"))
+	if err != nil {
+		return err
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	steps, err := resetSteps(device, policy)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("dry-run") {
+		for _, step := range steps {
+			fmt.Println("[dry-run]", step.Desc)
+		}
+		return nil
+	}
+	failed := 0
+	for _, step := range steps {
+		if err := step.Run(); err != nil {
+			failed++
+			fmt.Printf("%s ... failed: %v\n", step.Desc, err)
+		} else {
+			fmt.Printf("%s ... ok\n", step.Desc)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d reset steps failed", failed, len(steps))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCheckCleanPath(t *testing.T) {
+	for _, tc := range []struct {
+		path  string
+		valid bool
+	}{
+		{"/data/local/tmp/*", true},
+		{"/sdcard/fa-*", true},
+		{"/storage/emulated/0/test-output", true},
+		{"/sdcard/[ab]?.log", true},
+		{"/", false},
+		{"/sdcard/", false},
+		{"/sdcard", false},
+		{"/data/local/tmp/../../system", false},
+		{"/sdcard/a/..", false},
+		{"/sdcard//a", false},
+		{"/system/app", false},
+		{"/sdcard/a b", false},
+		{"/sdcard/a;reboot", false},
+		{"/sdcard/$(reboot)", false},
+		{"/sdcard/`reboot`", false},
+		{"/sdcard/a|b", false},
+	} {
+		if err := checkCleanPath(tc.path); (err == nil) != tc.valid {
+			t.Errorf("checkCleanPath(%q): %v", tc.path, err)
+		}
+	}
+}
+
+func TestKeepPackage(t *testing.T) {
+	patterns := []string{"com.github.uiautomator", "io.appium.*"}
+	for _, tc := range []struct {
+		name string
+		keep bool
+	}{
+		{"com.github.uiautomator", true},
+		{"com.github.uiautomator.test", false},
+		{"io.appium.settings", true},
+		{"io.appium", false},
+		{"com.example.demo", false},
+	} {
+		if keep := keepPackage(patterns, tc.name); keep != tc.keep {
+			t.Errorf("keepPackage(%q) = %v, expect %v", tc.name, keep, tc.keep)
+		}
+	}
+	if keepPackage(nil, "io.appium.settings") {
+		t.Error("nothing should be kept without patterns")
+	}
+}
+
+func writePolicyFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "fa-policy-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestLoadResetPolicy(t *testing.T) {
+	policy, err := loadResetPolicy("")
+	if err != nil || !reflect.DeepEqual(policy, defaultResetPolicy) {
+		t.Fatalf("expect default policy, got %+v, %v", policy, err)
+	}
+
+	filename := writePolicyFile(t, `
+uninstall: false
+keep_packages: [io.appium.*]
+clean: [/sdcard/test-output]
+settings:
+  global:
+    window_animation_scale: "0"
+    stay_on_while_plugged_in: ""
+  secure:
+    show_ime_with_hard_keyboard: "1"
+`)
+	defer os.Remove(filename)
+	policy, err = loadResetPolicy(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Uninstall || !policy.RemoveForwards {
+		t.Errorf("expect uninstall replaced and remove_forwards kept, got %+v", policy)
+	}
+	if !reflect.DeepEqual(policy.KeepPackages, []string{"io.appium.*"}) ||
+		!reflect.DeepEqual(policy.Clean, []string{"/sdcard/test-output"}) {
+		t.Errorf("expect lists replaced, got %v %v", policy.KeepPackages, policy.Clean)
+	}
+	global := policy.Settings["global"]
+	if global["window_animation_scale"] != "0" || global["animator_duration_scale"] != "1.0" {
+		t.Errorf("expect global settings merged, got %v", global)
+	}
+	if _, ok := global["stay_on_while_plugged_in"]; ok {
+		t.Errorf("expect empty setting skipped, got %v", global)
+	}
+	if policy.Settings["secure"]["show_ime_with_hard_keyboard"] != "1" || policy.Settings["system"]["screen_brightness"] != "128" {
+		t.Errorf("expect new namespace added and defaults kept, got %v", policy.Settings)
+	}
+	if defaultResetPolicy.Settings["global"]["window_animation_scale"] != "1.0" ||
+		defaultResetPolicy.Settings["global"]["stay_on_while_plugged_in"] != "0" {
+		t.Errorf("defaults should not be modified, got %v", defaultResetPolicy.Settings)
+	}
+
+	for _, content := range []string{
+		"clean: [/system/app]",
+		"clean: [\"/sdcard/a;reboot\"]",
+		"unknown_field: true",
+	} {
+		filename := writePolicyFile(t, content)
+		defer os.Remove(filename)
+		if _, err := loadResetPolicy(filename); err == nil {
+			t.Errorf("expect error for policy %q", content)
+		}
+	}
+}
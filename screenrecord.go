@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	shellquote "github.com/kballard/go-shellquote"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/codeskyblue/fa/mp4"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// screenrecord stops after 3 minutes, so record is split into segments
+const screenrecordTimeLimit = 180
+
+// recordSegment run screenrecord until time limit reached or stop received.
+// stopped is true if recording is stopped by user, error of screenrecord is ignored in that case.
+// screenrecord is started in background of the shell, so SIGINT is sent to the pid it started only
+func recordSegment(device *adb.Device, args []string, remotePath string, stop chan os.Signal) (stopped bool, err error) {
+	cmd := shellquote.Join(append(append([]string{"screenrecord"}, args...), remotePath)...) + " & echo $!; wait"
+	rwc, err := device.OpenShell(cmd)
+	if err != nil {
+		return false, err
+	}
+	defer rwc.Close()
+	start := time.Now()
+	reader := bufio.NewReader(rwc)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return false, errors.Wrap(err, "start screenrecord")
+	}
+	pid := strings.TrimSpace(line)
+	if _, err := strconv.Atoi(pid); err != nil {
+		return false, errors.New("start screenrecord: " + pid)
+	}
+	done := make(chan error, 1)
+	go func() {
+		data, err := ioutil.ReadAll(reader)
+		output := strings.TrimSpace(string(data))
+		if err == nil && output != "" && debug {
+			log.Println("screenrecord:", output)
+		}
+		if err == nil && time.Since(start) < time.Second {
+			err = errors.New("screenrecord exited unexpectedly: " + output)
+		}
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		select {
+		case <-stop: // Ctrl-C and exit of screenrecord arrived together
+			return true, nil
+		default:
+			return false, err
+		}
+	case <-stop:
+	}
+	// screenrecord finish the file when receive SIGINT, retry in case it was not ready to handle the signal
+	for {
+		device.RunCommand("kill", "-2", pid)
+		select {
+		case err := <-done:
+			if err != nil && debug {
+				log.Println("screenrecord:", err)
+			}
+			return true, nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// segmentRecorded returns false if screenrecord did not write anything to remotePath
+func segmentRecorded(device *adb.Device, remotePath string) bool {
+	info, err := device.Stat(remotePath)
+	return err == nil && info.Size() > 0
+}
+
+func actScreenrecord(ctx *cli.Context) error {
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	output := ctx.String("output")
+	args := []string{"--time-limit", strconv.Itoa(screenrecordTimeLimit)}
+	if ctx.String("size") != "" {
+		args = append(args, "--size", ctx.String("size"))
+	}
+	if ctx.Int("bit-rate") > 0 {
+		args = append(args, "--bit-rate", strconv.Itoa(ctx.Int("bit-rate")))
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+
+	var remotePaths []string
+	var recordErr error
+	prefix := fmt.Sprintf("/sdcard/fa-screenrecord-%d", time.Now().UnixNano())
+	fmt.Println("Recording, press Ctrl-C to stop ...")
+	for i := 0; ; i++ {
+		remotePath := fmt.Sprintf("%s-%d.mp4", prefix, i)
+		stopped, err := recordSegment(device, args, remotePath, stop)
+		if err != nil {
+			// keep segments already finished
+			device.RunCommand("rm", "-f", remotePath)
+			recordErr = err
+			break
+		}
+		if stopped {
+			// the last segment is empty if stopped before screenrecord wrote anything
+			if segmentRecorded(device, remotePath) {
+				remotePaths = append(remotePaths, remotePath)
+			} else {
+				device.RunCommand("rm", "-f", remotePath)
+			}
+			break
+		}
+		remotePaths = append(remotePaths, remotePath)
+		if debug {
+			log.Println("segment", i, "finished, restart screenrecord")
+		}
+	}
+	if len(remotePaths) == 0 {
+		if recordErr != nil {
+			return recordErr
+		}
+		return errors.New("nothing recorded")
+	}
+
+	outputs, err := pullAndConcat(device, output, remotePaths)
+	for _, name := range outputs {
+		log.Println("saved to", name)
+	}
+	if err != nil {
+		// segments are kept on device, so they can be pulled manually
+		log.Println("segments are kept on device:", strings.Join(remotePaths, " "))
+		return err
+	}
+	for _, remotePath := range remotePaths {
+		device.RunCommand("rm", remotePath)
+	}
+	if recordErr != nil {
+		return errors.Wrap(recordErr, "recording stopped early")
+	}
+	return nil
+}
+
+// pullAndConcat pull segments and concat them into output, see concatSegments
+func pullAndConcat(device *adb.Device, output string, remotePaths []string) (outputs []string, err error) {
+	tmpDir, err := ioutil.TempDir("", "fa-screenrecord")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+	localPaths := make([]string, 0, len(remotePaths))
+	for i, remotePath := range remotePaths {
+		localPath := filepath.Join(tmpDir, filepath.Base(remotePath))
+		fmt.Printf("Pull segment %d/%d ...\n", i+1, len(remotePaths))
+		if err = device.Pull(remotePath, localPath); err != nil {
+			return nil, errors.Wrap(err, "pull "+remotePath)
+		}
+		localPaths = append(localPaths, localPath)
+	}
+	return concatSegments(output, localPaths)
+}
+
+// concatSegments join segments into output. Segments can not be joined when screen rotated
+// or resolution changed, a new file with suffix -2, -3 ... is started in that case
+func concatSegments(output string, paths []string) (outputs []string, err error) {
+	ext := filepath.Ext(output)
+	for n := 1; len(paths) > 0; n++ {
+		name := output
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(output, ext), n, ext)
+		}
+		next := []string(nil)
+		err = mp4.ConcatFiles(name, paths...)
+		if serr, ok := err.(*mp4.SampleDescriptionError); ok {
+			next = paths[serr.Index:]
+			err = mp4.ConcatFiles(name, paths[:serr.Index]...)
+		}
+		if err != nil {
+			return
+		}
+		outputs = append(outputs, name)
+		paths = next
+	}
+	return
+}
@@ -1,65 +1,82 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"log"
 	"os"
-	"runtime"
-	"time"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/browser"
 	// "github.com/urfave/cli"
 	cli "gopkg.in/urfave/cli.v1"
 )
 
-func anyFuncs(funcs ...func() error) error {
-	var err error
-	for _, f := range funcs {
-		if err = f(); err == nil {
-			return nil
+// resizeImage scale image with nearest neighbor
+func resizeImage(img image.Image, scale float64) image.Image {
+	bounds := img.Bounds()
+	width := int(float64(bounds.Dx()) * scale)
+	height := int(float64(bounds.Dy()) * scale)
+	if width < 1 || height < 1 {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx := bounds.Min.X + int(float64(x)/scale)
+			sy := bounds.Min.Y + int(float64(y)/scale)
+			dst.Set(x, y, img.At(sx, sy))
 		}
 	}
-	return err
+	return dst
 }
 
-func takeScreenshot(serial, output string) error {
-	execOut := func() error {
-		c := adbCommand(serial, "exec-out", "screencap", "-p", "2>/dev/null")
-		imgfile, err := os.Create(output)
-		if err != nil {
-			return err
-		}
-		defer func() {
-			imgfile.Close()
-			if err != nil {
-				os.Remove(output)
-			}
-		}()
-		c.Stdout = imgfile
-		return c.Run()
+// saveImage encode image according to file extension, support .png .jpg .jpeg
+func saveImage(img image.Image, output string) (err error) {
+	var encode func(f *os.File) error
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".png":
+		encode = func(f *os.File) error { return png.Encode(f, img) }
+	case ".jpg", ".jpeg":
+		encode = func(f *os.File) error { return jpeg.Encode(f, img, &jpeg.Options{Quality: 90}) }
+	default:
+		return errors.New("unsupported image format: " + output)
 	}
-	screencap := func() error {
-		tmpPath := fmt.Sprintf("/sdcard/fa-screenshot-%d.png", time.Now().UnixNano())
-		c := adbCommand(serial, "shell", "screencap", "-p", tmpPath)
-		if err := c.Run(); err != nil {
-			return err
-		}
-		defer adbCommand(serial, "shell", "rm", tmpPath).Run()
-		return adbCommand(serial, "pull", tmpPath, output).Run()
-	}
-	if runtime.GOOS == "windows" {
-		return screencap()
+	imgfile, err := os.Create(output)
+	if err != nil {
+		return err
 	}
-	return anyFuncs(execOut, screencap)
+	defer func() {
+		imgfile.Close()
+		if err != nil {
+			os.Remove(output)
+		}
+	}()
+	return encode(imgfile)
 }
 
 func actScreenshot(ctx *cli.Context) (err error) {
-	serial, err := chooseOne()
+	scale := ctx.Float64("scale")
+	if scale <= 0 || scale > 1 {
+		return errors.New("scale should in range (0, 1]")
+	}
+	device, err := chooseDevice()
 	if err != nil {
 		return err
 	}
+	img, err := device.ScreenshotDisplay(context.Background(), ctx.String("display"))
+	if err != nil {
+		return err
+	}
+	if scale != 1 {
+		img = resizeImage(img, scale)
+	}
 	output := ctx.String("output")
-	err = takeScreenshot(serial, output)
+	err = saveImage(img, output)
 	if err == nil {
 		log.Println("saved to", output)
 		if ctx.Bool("open") {
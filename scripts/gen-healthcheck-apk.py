@@ -0,0 +1,150 @@
+#!/usr/bin/env python3
+# Generate healthcheck_apk.go, which contains a tiny signed apk without any code.
+# The apk is used by "fa healthcheck" to test install and uninstall.
+#
+# Usage: python3 scripts/gen-healthcheck-apk.py > healthcheck_apk.go
+# Require: openssl
+import base64
+import hashlib
+import io
+import os
+import struct
+import subprocess
+import tempfile
+import zipfile
+
+PACKAGE = "com.github.codeskyblue.fa.healthcheck"
+ANDROID_NS = "http://schemas.android.com/apk/res/android"
+
+# attribute name -> android resource id, must be in front of string pool
+ATTR_IDS = [
+    ("hasCode", 0x0101000c),
+    ("minSdkVersion", 0x0101020c),
+    ("versionCode", 0x0101021b),
+    ("versionName", 0x0101021c),
+    ("targetSdkVersion", 0x01010270),
+]
+
+TYPE_STRING = 0x03
+TYPE_INT_DEC = 0x10
+TYPE_INT_BOOLEAN = 0x12
+
+
+class StringPool(object):
+    def __init__(self):
+        self.strings = [name for name, _ in ATTR_IDS]
+
+    def index(self, s):
+        if s not in self.strings:
+            self.strings.append(s)
+        return self.strings.index(s)
+
+    def encode(self):
+        data = b""
+        offsets = []
+        for s in self.strings:
+            offsets.append(len(data))
+            u16 = s.encode("utf-16-le")
+            data += struct.pack("<H", len(s)) + u16 + b"\x00\x00"
+        while len(data) % 4:
+            data += b"\x00"
+        header_size = 28
+        strings_start = header_size + 4 * len(self.strings)
+        size = strings_start + len(data)
+        return struct.pack("<HHIIIIII", 0x0001, header_size, size,
+                           len(self.strings), 0, 0, strings_start, 0) + \
+            b"".join(struct.pack("<I", o) for o in offsets) + data
+
+
+def build_manifest():
+    pool = StringPool()
+    ns_prefix, ns_uri = pool.index("android"), pool.index(ANDROID_NS)
+    chunks = []
+
+    def attr(name, value, android=True):
+        ns = ns_uri if android else 0xffffffff
+        if isinstance(value, bool):
+            raw, typ, data = 0xffffffff, TYPE_INT_BOOLEAN, 0xffffffff if value else 0
+        elif isinstance(value, int):
+            raw, typ, data = 0xffffffff, TYPE_INT_DEC, value
+        else:
+            raw = data = pool.index(value)
+            typ = TYPE_STRING
+        return struct.pack("<IIIHBBI", ns, pool.index(name), raw, 8, 0, typ, data)
+
+    def start(name, attrs):
+        body = struct.pack("<IIHHHHHH", 0xffffffff, pool.index(name), 20, 20, len(attrs), 0, 0, 0)
+        body += b"".join(attrs)
+        chunks.append(struct.pack("<HHIII", 0x0102, 16, 16 + len(body), 1, 0xffffffff) + body)
+
+    def end(name):
+        chunks.append(struct.pack("<HHIIIII", 0x0103, 16, 24, 1, 0xffffffff, 0xffffffff, pool.index(name)))
+
+    # attributes are sorted by resource id, attributes without id go first
+    chunks.append(struct.pack("<HHIIIII", 0x0100, 16, 24, 1, 0xffffffff, ns_prefix, ns_uri))
+    start("manifest", [
+        attr("package", PACKAGE, android=False),
+        attr("versionCode", 1),
+        attr("versionName", "1.0"),
+    ])
+    start("uses-sdk", [
+        attr("minSdkVersion", 18),
+        attr("targetSdkVersion", 29),
+    ])
+    end("uses-sdk")
+    start("application", [attr("hasCode", False)])
+    end("application")
+    end("manifest")
+    chunks.append(struct.pack("<HHIIIII", 0x0101, 16, 24, 1, 0xffffffff, ns_prefix, ns_uri))
+
+    resmap = struct.pack("<HHI", 0x0180, 8, 8 + 4 * len(ATTR_IDS)) + \
+        b"".join(struct.pack("<I", rid) for _, rid in ATTR_IDS)
+    body = pool.encode() + resmap + b"".join(chunks)
+    return struct.pack("<HHI", 0x0003, 8, 8 + len(body)) + body
+
+
+def b64sha256(data):
+    return base64.b64encode(hashlib.sha256(data).digest()).decode()
+
+
+def build_apk():
+    manifest = build_manifest()
+    section = "Name: AndroidManifest.xml\r\nSHA-256-Digest: %s\r\n\r\n" % b64sha256(manifest)
+    mf = "Manifest-Version: 1.0\r\nCreated-By: 1.0 (fa)\r\n\r\n" + section
+    sf = "Signature-Version: 1.0\r\nCreated-By: 1.0 (fa)\r\nSHA-256-Digest-Manifest: %s\r\n\r\n" % b64sha256(mf.encode()) + \
+        "Name: AndroidManifest.xml\r\nSHA-256-Digest: %s\r\n\r\n" % b64sha256(section.encode())
+
+    tmpdir = tempfile.mkdtemp()
+    key, cert = os.path.join(tmpdir, "key.pem"), os.path.join(tmpdir, "cert.pem")
+    sfpath, rsapath = os.path.join(tmpdir, "CERT.SF"), os.path.join(tmpdir, "CERT.RSA")
+    subprocess.check_call(["openssl", "req", "-x509", "-newkey", "rsa:2048", "-nodes", "-days", "10950",
+                           "-subj", "/CN=fa healthcheck", "-keyout", key, "-out", cert],
+                          stderr=subprocess.DEVNULL)
+    with open(sfpath, "wb") as f:
+        f.write(sf.encode())
+    subprocess.check_call(["openssl", "smime", "-sign", "-binary", "-noattr", "-md", "sha256",
+                           "-outform", "DER", "-in", sfpath, "-signer", cert, "-inkey", key, "-out", rsapath])
+    with open(rsapath, "rb") as f:
+        rsa = f.read()
+
+    buf = io.BytesIO()
+    with zipfile.ZipFile(buf, "w", zipfile.ZIP_DEFLATED) as z:
+        z.writestr("AndroidManifest.xml", manifest)
+        z.writestr("META-INF/MANIFEST.MF", mf)
+        z.writestr("META-INF/CERT.SF", sf)
+        z.writestr("META-INF/CERT.RSA", rsa)
+    return buf.getvalue()
+
+
+def main():
+    data = base64.b64encode(build_apk()).decode()
+    lines = [data[i:i + 76] for i in range(0, len(data), 76)]
+    print("package main\n")
+    print("// Code generated by scripts/gen-healthcheck-apk.py. DO NOT EDIT.\n")
+    print("const healthcheckPackage = \"%s\"\n" % PACKAGE)
+    print("// healthcheckApkBase64 is a signed apk without code, used to test install and uninstall")
+    print("const healthcheckApkBase64 = `" + "\n".join(lines) + "`")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// connectAndWait connect to addr and wait until it is online, connect and wait share the timeout
+func connectAndWait(client *adb.Client, addr string, timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// adbd need a while to restart in tcp mode
+	for {
+		if err = client.ConnectContext(ctx, addr); err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Second):
+		}
+	}
+	if err = client.WaitForStateContext(ctx, addr, adb.StateOnline); err == context.DeadlineExceeded {
+		err = fmt.Errorf("wait for %s to be %s timeout", addr, adb.StateOnline)
+	}
+	return err
+}
+
+func actTcpip(ctx *cli.Context) error {
+	client := newClient()
+	timeout := ctx.Duration("timeout")
+	var addr string
+	if pairAddr := ctx.String("pair"); pairAddr != "" {
+		// Android 11+ wireless debugging, connect port differs from pairing port
+		if ctx.String("code") == "" {
+			return errors.New("--code is required for --pair")
+		}
+		if err := client.Pair(pairAddr, ctx.String("code")); err != nil {
+			return err
+		}
+		log.Println("Paired with", pairAddr)
+		if !ctx.Args().Present() {
+			return nil
+		}
+		addr = ctx.Args().First()
+	} else {
+		device, err := chooseDevice()
+		if err != nil {
+			return err
+		}
+		info, err := device.WifiInfo()
+		if err != nil {
+			return err
+		}
+		if info.IP == "" {
+			return errors.New("wlan ip not found, check device is connected to wifi")
+		}
+		port := ctx.Int("port")
+		if err := device.TCPIP(port); err != nil {
+			return err
+		}
+		addr = net.JoinHostPort(info.IP, strconv.Itoa(port))
+	}
+	if err := connectAndWait(client, addr, timeout); err != nil {
+		return err
+	}
+	log.Println("Connected to", addr)
+	if ctx.Bool("export") {
+		fmt.Printf("export ANDROID_SERIAL=%s\n", addr)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+func formatUINode(n *adb.UINode) string {
+	parts := []string{n.Class}
+	if n.ResourceID != "" {
+		parts = append(parts, "id="+n.ResourceID)
+	}
+	if n.Text != "" {
+		parts = append(parts, fmt.Sprintf("text=%q", n.Text))
+	}
+	if n.ContentDesc != "" {
+		parts = append(parts, fmt.Sprintf("desc=%q", n.ContentDesc))
+	}
+	if n.Clickable {
+		parts = append(parts, "clickable")
+	}
+	b := n.Bounds
+	parts = append(parts, fmt.Sprintf("[%d,%d][%d,%d]", b.Min.X, b.Min.Y, b.Max.X, b.Max.Y))
+	return strings.Join(parts, " ")
+}
+
+func actUIDump(ctx *cli.Context) error {
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("xml") {
+		data, err := device.DumpHierarchyXML()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	root, err := device.DumpHierarchy()
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		printJSON(root.Children)
+		return nil
+	}
+	for _, c := range root.Children {
+		c.Walk(func(n *adb.UINode, depth int) bool {
+			fmt.Println(strings.Repeat("  ", depth) + formatUINode(n))
+			return true
+		})
+	}
+	return nil
+}
+
+func uiSelector(ctx *cli.Context) (adb.UISelector, error) {
+	s := adb.UISelector{
+		Text:         ctx.String("text"),
+		TextContains: ctx.String("text-contains"),
+		ResourceID:   ctx.String("id"),
+		Class:        ctx.String("class"),
+		ContentDesc:  ctx.String("desc"),
+	}
+	if s == (adb.UISelector{}) {
+		return s, errors.New("at least one of --text, --text-contains, --id, --class, --desc should provided")
+	}
+	return s, nil
+}
+
+// findUINodes dump hierarchy until nodes found or timeout
+func findUINodes(device *adb.Device, s adb.UISelector, timeout time.Duration) (nodes []*adb.UINode, err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		root, err := device.DumpHierarchy()
+		if err != nil {
+			return nil, err
+		}
+		if nodes = root.Find(s); len(nodes) > 0 {
+			return nodes, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("ui node not found")
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func actUIFind(ctx *cli.Context) error {
+	s, err := uiSelector(ctx)
+	if err != nil {
+		return err
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	nodes, err := findUINodes(device, s, ctx.Duration("wait"))
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		printJSON(nodes)
+	} else {
+		for _, n := range nodes {
+			fmt.Println(formatUINode(n))
+		}
+	}
+	if ctx.Bool("click") {
+		center := nodes[0].Center()
+		return device.Tap(center.X, center.Y)
+	}
+	return nil
+}
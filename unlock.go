@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// parsePattern convert "1,2,3,6" to points index, keypad layout:
+//
+//	1 2 3
+//	4 5 6
+//	7 8 9
+func parsePattern(s string) (indexes []int, err error) {
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n < 1 || n > 9 {
+			return nil, fmt.Errorf("invalid pattern point: %q", v)
+		}
+		indexes = append(indexes, n)
+	}
+	if len(indexes) < 2 {
+		return nil, errors.New("pattern require at least 2 points")
+	}
+	return
+}
+
+// patternPoints returns screen coordinates of pattern points inside lock view bounds
+func patternPoints(bounds image.Rectangle, indexes []int) []image.Point {
+	points := make([]image.Point, 0, len(indexes))
+	for _, n := range indexes {
+		row, col := (n-1)/3, (n-1)%3
+		points = append(points, image.Pt(
+			bounds.Min.X+bounds.Dx()*(2*col+1)/6,
+			bounds.Min.Y+bounds.Dy()*(2*row+1)/6))
+	}
+	return points
+}
+
+// swipeUpKeyguard swipe from bottom to show bouncer
+func swipeUpKeyguard(device *adb.Device) error {
+	root, err := device.DumpHierarchy()
+	if err != nil {
+		return err
+	}
+	var screen image.Rectangle
+	for _, n := range root.Children {
+		screen = screen.Union(n.Bounds)
+	}
+	if screen.Empty() {
+		return errors.New("screen size unknown")
+	}
+	x := screen.Dx() / 2
+	return device.Swipe(x, screen.Dy()*9/10, x, screen.Dy()*3/10, 300*time.Millisecond)
+}
+
+func drawPattern(device *adb.Device, indexes []int) error {
+	nodes, err := findUINodes(device, adb.UISelector{ResourceID: "lockPatternView"}, 2*time.Second)
+	if err != nil {
+		root, er := device.DumpHierarchy()
+		if er != nil {
+			return er
+		}
+		if nodes = root.Find(adb.UISelector{Class: "LockPatternView"}); len(nodes) == 0 {
+			return errors.New("lock pattern view not found")
+		}
+	}
+	return device.Gesture(patternPoints(nodes[0].Bounds, indexes), 100*time.Millisecond)
+}
+
+// waitUnlocked returns true if keyguard gone in timeout
+func waitUnlocked(device *adb.Device, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := device.IsLocked()
+		if err != nil {
+			return false, err
+		}
+		if !locked {
+			return true, nil
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func actUnlock(ctx *cli.Context) error {
+	pin, pattern := ctx.String("pin"), ctx.String("pattern")
+	if pin != "" && pattern != "" {
+		return errors.New("--pin and --pattern can not be used together")
+	}
+	var indexes []int
+	if pattern != "" {
+		var err error
+		if indexes, err = parsePattern(pattern); err != nil {
+			return err
+		}
+	}
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	if pattern != "" {
+		props, err := device.Properties()
+		if err != nil {
+			return err
+		}
+		if sdk, _ := strconv.Atoi(strings.TrimSpace(string(props["ro.build.version.sdk"]))); sdk < 28 {
+			// pattern is drawn with input motionevent, which is added in Android 9
+			return errors.New("pattern unlock require Android 9+, unlock with --pin or by hand")
+		}
+	}
+	if err := device.WakeUp(); err != nil {
+		return errors.Wrap(err, "wake up")
+	}
+	if unlocked, err := waitUnlocked(device, 0); err != nil || unlocked {
+		if unlocked {
+			fmt.Println("Unlocked")
+		}
+		return err
+	}
+
+	// dismiss keyguard without credential, wm dismiss-keyguard is available since Android 8.0
+	device.RunCommand("wm", "dismiss-keyguard")
+	device.KeyEvent("MENU")
+	if unlocked, err := waitUnlocked(device, time.Second); err != nil || unlocked {
+		if unlocked {
+			fmt.Println("Unlocked")
+		}
+		return err
+	}
+
+	if pin != "" || pattern != "" {
+		swipeUpKeyguard(device)
+		time.Sleep(500 * time.Millisecond)
+	}
+	switch {
+	case pin != "":
+		if err := device.Text(pin); err != nil {
+			return err
+		}
+		if err := device.KeyEvent("ENTER"); err != nil {
+			return err
+		}
+	case pattern != "":
+		if err := drawPattern(device, indexes); err != nil {
+			return err
+		}
+	}
+	unlocked, err := waitUnlocked(device, 3*time.Second)
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return errors.New("device is still locked")
+	}
+	fmt.Println("Unlocked")
+	return nil
+}
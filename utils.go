@@ -1,6 +1,16 @@
 package main
 
-import "net"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
 
 func GetLocalIP() string {
 	addrs, err := net.InterfaceAddrs()
@@ -17,3 +27,36 @@ func GetLocalIP() string {
 	}
 	return "localhost"
 }
+
+func printJSON(v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+func runCommand(name string, args ...string) (err error) {
+	if filepath.Base(name) == name {
+		name, err = exec.LookPath(name)
+		if err != nil {
+			return err
+		}
+	}
+	procAttr := new(os.ProcAttr)
+	procAttr.Files = []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	proc, err := os.StartProcess(name, append([]string{name}, args...), procAttr)
+	if err != nil {
+		return err
+	}
+	procState, err := proc.Wait()
+	if err != nil {
+		return err
+	}
+	ws, ok := procState.Sys().(syscall.WaitStatus)
+	if !ok {
+		return errors.New("exit code unknown")
+	}
+	exitCode := ws.ExitStatus()
+	if exitCode == 0 {
+		return nil
+	}
+	return errors.New("exit code " + strconv.Itoa(exitCode))
+}
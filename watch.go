@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// eventDebouncer merge events of the same device in delay, so flapping devices trigger hooks once
+type eventDebouncer struct {
+	delay   time.Duration
+	emit    func(adb.DeviceEvent)
+	mu      sync.Mutex
+	pending map[string]*pendingEvent
+}
+
+type pendingEvent struct {
+	ev    adb.DeviceEvent
+	timer *time.Timer
+}
+
+func newEventDebouncer(delay time.Duration, emit func(adb.DeviceEvent)) *eventDebouncer {
+	return &eventDebouncer{
+		delay:   delay,
+		emit:    emit,
+		pending: make(map[string]*pendingEvent),
+	}
+}
+
+// Add emit merged event after no new event of the device in delay,
+// nothing is emitted if the device returns to the state before the first event
+func (d *eventDebouncer) Add(ev adb.DeviceEvent) {
+	if d.delay <= 0 {
+		d.emit(ev)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if p, ok := d.pending[ev.Serial]; ok {
+		p.ev.NewState = ev.NewState
+		p.timer.Reset(d.delay)
+		return
+	}
+	p := &pendingEvent{ev: ev}
+	p.timer = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		if d.pending[ev.Serial] != p {
+			d.mu.Unlock()
+			return
+		}
+		delete(d.pending, ev.Serial)
+		merged := p.ev
+		d.mu.Unlock()
+		if merged.OldState != merged.NewState {
+			d.emit(merged)
+		}
+	})
+	d.pending[ev.Serial] = p
+}
+
+// deviceTransport guess how device is connected from serial
+func deviceTransport(serial string) string {
+	switch {
+	case strings.HasPrefix(serial, "emulator-"):
+		return "emulator"
+	case strings.Contains(serial, ":"):
+		return "tcp"
+	default:
+		return "usb"
+	}
+}
+
+// propertyCache keeps properties of devices, so they are available when device went offline
+type propertyCache struct {
+	client *adb.Client
+	mu     sync.Mutex
+	props  map[string]map[string]adb.PropValue
+}
+
+// propertyFetchTimeout limits time of getprop, so a hung device does not block events of others
+const propertyFetchTimeout = 3 * time.Second
+
+// Fetch returns device properties, cached value is used if device is not online or getprop failed
+func (c *propertyCache) Fetch(ev adb.DeviceEvent) map[string]adb.PropValue {
+	if ev.NewState == adb.StateOnline {
+		ctx, cancel := context.WithTimeout(context.Background(), propertyFetchTimeout)
+		defer cancel()
+		props, err := c.client.DeviceWithSerial(ev.Serial).PropertiesContext(ctx)
+		if err == nil {
+			c.mu.Lock()
+			c.props[ev.Serial] = props
+			c.mu.Unlock()
+			return props
+		}
+	}
+	return c.Lookup(ev.Serial)
+}
+
+// Lookup returns cached properties
+func (c *propertyCache) Lookup(serial string) map[string]adb.PropValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.props[serial]
+}
+
+// hookWaitDelay is the time to wait for output after hook exited or killed
+const hookWaitDelay = time.Second
+
+// watchHooks run hook commands concurrently, output is logged with serial prefix
+type watchHooks struct {
+	props       *propertyCache
+	onlineCmd   string
+	offlineCmd  string
+	stateCmd    string
+	timeout     time.Duration
+	concurrency chan struct{}
+}
+
+// Handle start hooks of event in background
+func (h *watchHooks) Handle(ev adb.DeviceEvent) {
+	hooks := make(map[string]string)
+	if ev.CameOnline() && h.onlineCmd != "" {
+		hooks["online"] = h.onlineCmd
+	}
+	if ev.WentOffline() && h.offlineCmd != "" {
+		hooks["offline"] = h.offlineCmd
+	}
+	if h.stateCmd != "" {
+		hooks["state"] = h.stateCmd
+	}
+	props := h.props.Lookup(ev.Serial)
+	env := append(os.Environ(),
+		"SERIAL="+ev.Serial,
+		"OLD_STATE="+string(ev.OldState),
+		"NEW_STATE="+string(ev.NewState),
+		"MODEL="+string(props["ro.product.model"]),
+		"SDK="+string(props["ro.build.version.sdk"]),
+		"TRANSPORT="+deviceTransport(ev.Serial),
+	)
+	for name, command := range hooks {
+		go h.run(ev.Serial+" "+name, command, env)
+	}
+}
+
+func (h *watchHooks) run(tag string, command string, env []string) {
+	h.concurrency <- struct{}{}
+	defer func() { <-h.concurrency }()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/c", command)
+	} else {
+		cmd = exec.Command("bash", "-c", command)
+	}
+	cmd.Env = env
+	setHookProcessGroup(cmd)
+	// os.Pipe is used instead of io.Pipe, so Wait returns when hook exited
+	// even if the pipe is still held by background processes
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		log.Printf("[%s] hook failed: %v", tag, err)
+		return
+	}
+	defer pr.Close()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	start := time.Now()
+	err = cmd.Start()
+	pw.Close()
+	if err != nil {
+		log.Printf("[%s] hook failed: %v", tag, err)
+		return
+	}
+	logDone := make(chan struct{})
+	go func() {
+		defer close(logDone)
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			log.Printf("[%s] %s", tag, scanner.Text())
+		}
+		io.Copy(ioutil.Discard, pr)
+	}()
+	var timedOut int32
+	var timer *time.Timer
+	if h.timeout > 0 {
+		timer = time.AfterFunc(h.timeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			killHookProcess(cmd)
+		})
+	}
+	err = cmd.Wait()
+	if timer != nil {
+		timer.Stop()
+	}
+	discarded := false
+	select {
+	case <-logDone:
+	case <-time.After(hookWaitDelay):
+		discarded = true
+		pr.Close()
+	}
+	switch {
+	case atomic.LoadInt32(&timedOut) == 1:
+		log.Printf("[%s] hook timeout after %v", tag, h.timeout)
+	case err != nil:
+		log.Printf("[%s] hook failed: %v", tag, err)
+	case discarded:
+		log.Printf("[%s] hook finished, output of background processes is discarded", tag)
+	case debug:
+		log.Printf("[%s] hook finished in %v", tag, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// watchEvent is printed with --json and posted to --webhook
+type watchEvent struct {
+	Serial     string            `json:"serial"`
+	OldState   string            `json:"oldState"`
+	NewState   string            `json:"newState"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Transport  string            `json:"transport"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// properties included in watchEvent
+var watchEventProperties = []string{
+	"ro.product.brand",
+	"ro.product.model",
+	"ro.product.cpu.abi",
+	"ro.build.version.release",
+	"ro.build.version.sdk",
+}
+
+func newWatchEvent(ev adb.DeviceEvent, props map[string]adb.PropValue) watchEvent {
+	e := watchEvent{
+		Serial:    ev.Serial,
+		OldState:  string(ev.OldState),
+		NewState:  string(ev.NewState),
+		Timestamp: time.Now(),
+		Transport: deviceTransport(ev.Serial),
+	}
+	if props != nil {
+		e.Properties = make(map[string]string)
+		for _, name := range watchEventProperties {
+			e.Properties[name] = string(props[name])
+		}
+	}
+	return e
+}
+
+func actWatch(ctx *cli.Context) error {
+	if ctx.Int("hook-concurrency") <= 0 {
+		return fmt.Errorf("hook-concurrency should be positive")
+	}
+	client := newClient()
+	props := &propertyCache{
+		client: client,
+		props:  make(map[string]map[string]adb.PropValue),
+	}
+	hooks := &watchHooks{
+		props:       props,
+		onlineCmd:   ctx.String("online-hook-cmd"),
+		offlineCmd:  ctx.String("offline-hook-cmd"),
+		stateCmd:    ctx.String("state-hook-cmd"),
+		timeout:     ctx.Duration("hook-timeout"),
+		concurrency: make(chan struct{}, ctx.Int("hook-concurrency")),
+	}
+	var webhook *webhookQueue
+	if url := ctx.String("webhook"); url != "" {
+		var err error
+		webhook, err = newWebhookQueue(url, ctx.String("webhook-queue-dir"))
+		if err != nil {
+			return err
+		}
+		go webhook.Run()
+	}
+	debouncer := newEventDebouncer(ctx.Duration("debounce"), hooks.Handle)
+
+	// events are handled in order, watch is not blocked by fetching properties
+	eventC := make(chan adb.DeviceEvent, 100)
+	go func() {
+		for ev := range client.Watch(context.Background()) {
+			eventC <- ev
+		}
+		close(eventC)
+	}()
+	for ev := range eventC {
+		e := newWatchEvent(ev, props.Fetch(ev))
+		if ctx.Bool("json") {
+			data, _ := json.Marshal(e)
+			fmt.Println(string(data))
+		} else {
+			fmt.Println(ev)
+		}
+		if webhook != nil {
+			if err := webhook.Push(e); err != nil {
+				log.Println("webhook:", err)
+			}
+		}
+		debouncer.Add(ev)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codeskyblue/fa/adb"
+)
+
+// debounceRecorder collects events emitted by eventDebouncer
+type debounceRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *debounceRecorder) emit(ev adb.DeviceEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev.String())
+}
+
+func (r *debounceRecorder) Events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.events...)
+}
+
+func deviceEvent(serial string, oldState, newState adb.DeviceState) adb.DeviceEvent {
+	return adb.DeviceEvent{Serial: serial, OldState: oldState, NewState: newState}
+}
+
+func TestEventDebouncerNoDelay(t *testing.T) {
+	r := &debounceRecorder{}
+	d := newEventDebouncer(0, r.emit)
+	d.Add(deviceEvent("a", adb.StateOnline, adb.StateOffline))
+	d.Add(deviceEvent("a", adb.StateOffline, adb.StateOnline))
+	expect := []string{"a: device->offline", "a: offline->device"}
+	if events := r.Events(); !reflect.DeepEqual(events, expect) {
+		t.Errorf("expect %v, got %v", expect, events)
+	}
+}
+
+func TestEventDebouncerCollapse(t *testing.T) {
+	r := &debounceRecorder{}
+	d := newEventDebouncer(50*time.Millisecond, r.emit)
+	// a flaps and returns to online, b goes through offline to unauthorized
+	d.Add(deviceEvent("a", adb.StateOnline, adb.StateOffline))
+	d.Add(deviceEvent("b", adb.StateOnline, adb.StateOffline))
+	d.Add(deviceEvent("a", adb.StateOffline, adb.StateOnline))
+	d.Add(deviceEvent("b", adb.StateOffline, adb.StateUnauthorized))
+	time.Sleep(200 * time.Millisecond)
+	expect := []string{"b: device->unauthorized"}
+	if events := r.Events(); !reflect.DeepEqual(events, expect) {
+		t.Errorf("expect %v, got %v", expect, events)
+	}
+}
+
+func TestEventDebouncerOrder(t *testing.T) {
+	r := &debounceRecorder{}
+	d := newEventDebouncer(100*time.Millisecond, r.emit)
+	d.Add(deviceEvent("a", adb.StateDisconnected, adb.StateOffline))
+	time.Sleep(20 * time.Millisecond)
+	d.Add(deviceEvent("b", adb.StateDisconnected, adb.StateOnline))
+	time.Sleep(40 * time.Millisecond)
+	// delay of a restarts, so b is emitted first
+	d.Add(deviceEvent("a", adb.StateOffline, adb.StateOnline))
+	time.Sleep(300 * time.Millisecond)
+	expect := []string{"b: disconnected->device", "a: disconnected->device"}
+	if events := r.Events(); !reflect.DeepEqual(events, expect) {
+		t.Errorf("expect %v, got %v", expect, events)
+	}
+}
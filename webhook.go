@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backoff of retry, doubled after each failure
+var (
+	webhookMinBackoff = time.Second
+	webhookMaxBackoff = time.Minute
+)
+
+// webhookQueue post events to url in order.
+// Events are saved in dir before delivery, so they are not lost when fa is restarted or url is down
+type webhookQueue struct {
+	url    string
+	dir    string
+	client *http.Client
+	notify chan struct{}
+
+	mu  sync.Mutex
+	seq int
+}
+
+func newWebhookQueue(url, dir string) (*webhookQueue, error) {
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".fa", "webhook-queue")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &webhookQueue{
+		url:    url,
+		dir:    dir,
+		client: &http.Client{Timeout: 10 * time.Second},
+		notify: make(chan struct{}, 1),
+	}, nil
+}
+
+// Push save v to queue, file is renamed after written so Run never reads partial file
+func (q *webhookQueue) Push(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), q.seq%1000000)
+	q.mu.Unlock()
+	tmpPath := filepath.Join(q.dir, name+".tmp")
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(q.dir, name)); err != nil {
+		return err
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pending returns queued files, oldest first
+func (q *webhookQueue) pending() ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(q.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// post returns retry=false if server rejects the event, such event is dropped
+func (q *webhookQueue) post(data []byte) (retry bool, err error) {
+	resp, err := q.client.Post(q.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	// 4xx except 408 and 429 will not succeed with the same event
+	retry = resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return retry, err
+}
+
+// deliver post file until success or rejected
+func (q *webhookQueue) deliver(path string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Println("webhook:", err)
+		os.Remove(path)
+		return
+	}
+	backoff := webhookMinBackoff
+	for {
+		retry, err := q.post(data)
+		if err == nil {
+			break
+		}
+		if !retry {
+			log.Printf("webhook: drop %s: %v", filepath.Base(path), err)
+			break
+		}
+		log.Printf("webhook: %v, retry in %v", err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+	os.Remove(path)
+}
+
+// Run deliver queued events forever, events left by last run are delivered first
+func (q *webhookQueue) Run() {
+	for {
+		names, err := q.pending()
+		if err != nil {
+			log.Println("webhook:", err)
+		}
+		for _, name := range names {
+			q.deliver(name)
+		}
+		if len(names) == 0 {
+			<-q.notify
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookServer replies status in order, the last status is used when statuses run out
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []string // ids of events in request order
+}
+
+func newWebhookServer(statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev struct{ ID string }
+		json.NewDecoder(r.Body).Decode(&ev)
+		s.mu.Lock()
+		status := s.statuses[0]
+		if len(s.statuses) > 1 {
+			s.statuses = s.statuses[1:]
+		}
+		s.received = append(s.received, ev.ID)
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return s
+}
+
+func (s *webhookServer) Received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.received...)
+}
+
+// waitQueueEmpty wait until all files in dir are delivered or dropped
+func waitQueueEmpty(t *testing.T, q *webhookQueue) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if names, _ := q.pending(); len(names) == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("webhook queue not delivered in time")
+}
+
+func newTestWebhookQueue(t *testing.T, url string) (q *webhookQueue, cleanup func()) {
+	dir, err := ioutil.TempDir("", "fa-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err = newWebhookQueue(url, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minBackoff := webhookMinBackoff
+	webhookMinBackoff = 10 * time.Millisecond
+	return q, func() {
+		webhookMinBackoff = minBackoff
+		os.RemoveAll(dir)
+	}
+}
+
+type testEvent struct {
+	ID string
+}
+
+func TestWebhookRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		received []string
+	}{
+		{"retry on 5xx", []int{500, 503, 200}, []string{"a", "a", "a", "b"}},
+		{"retry on 429", []int{429, 200}, []string{"a", "a", "b"}},
+		{"drop on 4xx", []int{400, 200}, []string{"a", "b"}},
+	} {
+		server := newWebhookServer(tc.statuses...)
+		q, cleanup := newTestWebhookQueue(t, server.URL)
+		q.Push(testEvent{"a"})
+		q.Push(testEvent{"b"})
+		go q.Run()
+		waitQueueEmpty(t, q)
+		if received := server.Received(); !reflect.DeepEqual(received, tc.received) {
+			t.Errorf("%s: expect %v, got %v", tc.name, tc.received, received)
+		}
+		server.Close()
+		cleanup()
+	}
+}
+
+func TestWebhookOrder(t *testing.T) {
+	server := newWebhookServer(200)
+	defer server.Close()
+	q, cleanup := newTestWebhookQueue(t, server.URL)
+	defer cleanup()
+	go q.Run()
+	expect := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	for _, id := range expect {
+		if err := q.Push(testEvent{id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitQueueEmpty(t, q)
+	if received := server.Received(); !reflect.DeepEqual(received, expect) {
+		t.Errorf("expect %v, got %v", expect, received)
+	}
+}
+
+func TestWebhookReplayAfterRestart(t *testing.T) {
+	server := newWebhookServer(200)
+	defer server.Close()
+	// events are queued while fa is not running Run, like exited before delivery
+	q, cleanup := newTestWebhookQueue(t, server.URL)
+	defer cleanup()
+	for _, id := range []string{"a", "b", "c"} {
+		q.Push(testEvent{id})
+	}
+	if received := server.Received(); len(received) != 0 {
+		t.Fatalf("nothing should be delivered before Run, got %v", received)
+	}
+
+	restarted, err := newWebhookQueue(server.URL, q.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go restarted.Run()
+	waitQueueEmpty(t, restarted)
+	if received := server.Received(); !reflect.DeepEqual(received, []string{"a", "b", "c"}) {
+		t.Errorf("expect queued events replayed in order, got %v", received)
+	}
+}
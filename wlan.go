@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codeskyblue/fa/adb"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+func printWifiInfo(info adb.WifiInfo) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	state := "disabled"
+	if info.Enabled {
+		state = "disconnected"
+		if info.Connected {
+			state = "connected"
+		}
+	}
+	fmt.Fprintf(w, "State:\t%s\n", state)
+	if info.Connected {
+		fmt.Fprintf(w, "SSID:\t%s\n", info.SSID)
+		fmt.Fprintf(w, "BSSID:\t%s\n", info.BSSID)
+		fmt.Fprintf(w, "RSSI:\t%d dBm\n", info.RSSI)
+		fmt.Fprintf(w, "Link speed:\t%d Mbps\n", info.LinkSpeed)
+		fmt.Fprintf(w, "Frequency:\t%d MHz\n", info.Frequency)
+	}
+	fmt.Fprintf(w, "IP:\t%s\n", info.IP)
+	fmt.Fprintf(w, "MAC:\t%s\n", info.MAC)
+	w.Flush()
+}
+
+func actWlanStatus(ctx *cli.Context) error {
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	info, err := device.WifiInfo()
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		printJSON(info)
+	} else {
+		printWifiInfo(info)
+	}
+	return nil
+}
+
+func actWlanSwitch(enabled bool) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		device, err := chooseDevice()
+		if err != nil {
+			return err
+		}
+		return device.SetWifiEnabled(enabled)
+	}
+}
+
+func actWlanConnect(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return errors.New("ssid is required")
+	}
+	ssid := ctx.Args().First()
+	device, err := chooseDevice()
+	if err != nil {
+		return err
+	}
+	props, err := device.Properties()
+	if err != nil {
+		return err
+	}
+	if sdk, _ := strconv.Atoi(strings.TrimSpace(string(props["ro.build.version.sdk"]))); sdk < 30 {
+		// cmd wifi connect-network is added in Android 11
+		return errors.New("wlan connect require Android 11+")
+	}
+	if err := device.SetWifiEnabled(true); err != nil {
+		return err
+	}
+	if err := device.WifiConnect(ssid, ctx.String("password"), ctx.String("security")); err != nil {
+		return err
+	}
+	info, err := device.WaitWifiConnected(ssid, ctx.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("json") {
+		printJSON(info)
+	} else {
+		fmt.Printf("Connected to %s, IP: %s\n", info.SSID, info.IP)
+	}
+	return nil
+}